@@ -1,13 +1,18 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/cxpsemea/cx1e2e/pkg/process"
@@ -15,28 +20,40 @@ import (
 	easy "github.com/t-tomalak/logrus-easy-formatter"
 )
 
-func main() {
-	retval := run()
+// exit codes, documented in the README
+const (
+	EXIT_PASS        = 0 // all tests passed, or the QualityGate passed
+	EXIT_FAIL        = 1 // all tests failed
+	EXIT_PARTIAL     = 2 // some tests failed
+	EXIT_GATE_FAILED = 3 // the QualityGate failed
+	EXIT_CONFIG      = 4 // invalid configuration or command-line
+	EXIT_AUTH        = 5 // could not connect or authenticate to CheckmarxOne
+	EXIT_INTERRUPTED = 6 // the run was interrupted or timed out
+)
 
-	if retval == 0 {
-		os.Exit(1) // all tests failed
-	}
+func main() {
+	os.Exit(run())
+}
 
-	if retval == 1 {
-		os.Exit(0) // all tests passed
+// the quality gate decides the outcome of a run if it is configured, otherwise the share of tests which passed
+func getExitCode(status float32, interrupted bool, gate *process.QualityGateResult) int {
+	switch {
+	case interrupted:
+		return EXIT_INTERRUPTED
+	case gate != nil && gate.Passed:
+		return EXIT_PASS
+	case gate != nil:
+		return EXIT_GATE_FAILED
+	case status >= 1:
+		return EXIT_PASS
+	case status <= 0:
+		return EXIT_FAIL
 	}
-
-	os.Exit(2) // partial success
+	return EXIT_PARTIAL
 }
 
-func run() float32 {
-	logger := logrus.New()
-	logger.SetLevel(logrus.InfoLevel)
-	myformatter := &easy.Formatter{}
-	myformatter.TimestampFormat = "2006-01-02 15:04:05.000"
-	myformatter.LogFormat = "[%lvl%][%time%] %msg%\n"
-	logger.SetFormatter(myformatter)
-	logger.SetOutput(os.Stdout)
+func run() int {
+	logger := newLogger(logrus.InfoLevel, "")
 
 	testConfig := flag.String("config", "", "Path to a test config.yaml")
 	APIKey := flag.String("apikey", "", "CheckmarxOne API Key (if not using client id/secret)")
@@ -46,22 +63,63 @@ func run() float32 {
 	IAMURL := flag.String("iam", "", "Optional: CheckmarxOne IAM URL, if not defined in the test config.yaml")
 	Tenant := flag.String("tenant", "", "Optional: CheckmarxOne tenant, if not defined in the test config.yaml")
 	LogLevel := flag.String("log", "INFO", "Log level: TRACE, DEBUG, INFO, WARNING, ERROR, FATAL")
-	ReportType := flag.String("report-type", "html,json", "Report output format: html or json")
+	ReportType := flag.String("report-type", "html,json", "Report output formats, comma-separated: html, json, junit, markdown")
 	ReportName := flag.String("report-name", "cx1e2e_result", "Report output base name")
 	Engines := flag.String("engines", "sast,sca,kics,apisec", "Run tests only for these engines")
+	AutoTeardown := flag.Bool("auto-teardown", false, "Delete objects created during the run which were not deleted by a test, also when the run is interrupted")
+	CancelOnInterrupt := flag.Bool("cancel-on-interrupt", false, "Cancel scans which are still running when the run is interrupted or times out")
+	StepSummary := flag.Bool("step-summary", false, "Append the markdown report to the file named by the GITHUB_STEP_SUMMARY environment variable, when it is set")
+	RunTimeout := flag.Duration("run-timeout", 0, "Optional: abort the run after this duration (eg: 2h30m), remaining tests are skipped")
+	Include := flag.String("include", "", "Optional: run only tests matching these comma-separated selectors, eg: set:Access*,module:Scan+op:C,tag:smoke")
+	Exclude := flag.String("exclude", "", "Optional: skip tests matching these comma-separated selectors, same syntax as --include")
+	Plan := flag.Bool("plan", false, "Only load and validate the test config.yaml and print the tests which would run, without connecting to CheckmarxOne")
+	Lenient := flag.Bool("lenient", false, "Ignore unknown keys in the test config.yaml instead of failing, for configurations written for older versions")
+	Checkpoint := flag.String("checkpoint", "", "Optional: file to which the progress of the run is written after every test, 'none' to disable. Default: <report-name>_checkpoint.json")
+	Resume := flag.String("resume", "", "Optional: checkpoint file of an interrupted run to continue from the next test which did not run yet")
+	Concurrency := flag.Int("concurrency", 0, "Optional: maximum number of tests to run concurrently within test sets marked as Parallel, if not defined in the test config.yaml")
+	RateLimit := flag.Float64("rate-limit", 0, "Optional: maximum requests per second to each of the CheckmarxOne and IAM hosts, overrides the RateLimit in the test config.yaml")
+	FailOnSlow := flag.Bool("fail-on-slow", false, "Count tests which passed but took longer than their MaxDuration as failed for the exit code")
+	Load := flag.Bool("load", false, "Load-testing mode: repeat the selected test sets across --load-workers workers, and report throughput, error rate and duration percentiles")
+	LoadWorkers := flag.Int("load-workers", 10, "Load-testing mode: number of iterations running at the same time")
+	LoadIterations := flag.Int("load-iterations", 0, "Load-testing mode: total number of iterations across all workers. Default: one per worker, or no limit with --load-duration")
+	LoadDuration := flag.Duration("load-duration", 0, "Load-testing mode: start new iterations until this duration has passed (eg: 10m)")
+	Soak := flag.Duration("soak", 0, "Soak-testing mode: run the selected test sets again and again until this duration has passed (eg: 8h), and report how the results changed over time")
+	ParallelEnvironments := flag.Bool("parallel-environments", false, "Run against all Environments of the test config.yaml at the same time instead of one after the other")
 
 	flag.Parse()
 
-	if *testConfig == "" || (*APIKey == "" && (*ClientID == "" || *ClientSecret == "")) {
+	var checkpoint *process.Checkpoint
+	if *Resume != "" {
+		var err error
+		checkpoint, err = process.LoadCheckpoint(*Resume)
+		if err != nil {
+			logger.Errorf("Failed to load checkpoint %v: %s", *Resume, err)
+			return EXIT_CONFIG
+		}
+		if *testConfig == "" {
+			*testConfig = checkpoint.ConfigPath
+		}
+		os.Setenv("E2E_RUN_SUFFIX", checkpoint.E2ESuffix) // object names in the configuration must match the resumed run
+	}
+
+	if *testConfig == "" {
 		logger.Info("The purpose of this tool is to automate testing of the API for various workflows based on the yaml configuration. For help run: cx1e2e.exe -h")
-		logger.Fatalf("Test configuration yaml or authentication (API Key or client+secret) not provided.")
+		logger.Errorf("Test configuration yaml not provided.")
+		return EXIT_CONFIG
 	}
 
 	var err error
-	Config, err := process.LoadConfig(logger, *testConfig)
+	options := process.ConfigOptions{Lenient: *Lenient}
+	Config, err := process.LoadConfig(logger, *testConfig, options)
 	if err != nil {
-		logger.Fatalf("Failed to load configuration file %v: %s", *testConfig, err)
-		return 0
+		logger.Errorf("Failed to load configuration file %v: %s", *testConfig, err)
+		return EXIT_CONFIG
+	}
+
+	if !*Plan && len(Config.Environments) == 0 && *APIKey == "" && (*ClientID == "" || *ClientSecret == "") {
+		logger.Info("The purpose of this tool is to automate testing of the API for various workflows based on the yaml configuration. For help run: cx1e2e.exe -h")
+		logger.Errorf("Authentication (API Key or client+secret) not provided.")
+		return EXIT_CONFIG
 	}
 
 	if *LogLevel == "" {
@@ -91,33 +149,280 @@ func run() float32 {
 		logger.Info("Log level set to default: INFO")
 	}
 
-	if *ReportName != "" {
-		Config.ReportName = *ReportName
+	// the command-line settings are applied to the configuration of every environment, env is empty when not using Environments
+	applySettings := func(Config *process.TestConfig, env string) error {
+		if *ReportName != "" {
+			Config.ReportName = *ReportName
+		}
+		if Config.ReportName == "" {
+			Config.ReportName = "cx1e2e_result"
+		}
+		if env != "" {
+			Config.ReportName = fmt.Sprintf("%v_%v", Config.ReportName, env)
+		}
+
+		if *ReportType != "" {
+			Config.ReportType = strings.ToLower(*ReportType)
+		}
+		if Config.ReportType == "" {
+			Config.ReportType = "html,json"
+		} else if err := process.ValidateReportType(Config.ReportType); err != nil {
+			return fmt.Errorf("invalid report type (%v): %s", Config.ReportType, err)
+		}
+
+		if *AutoTeardown {
+			Config.AutoTeardown = true
+		}
+		if *FailOnSlow {
+			Config.FailOnSlow = true
+		}
+		if *CancelOnInterrupt {
+			Config.CancelOnInterrupt = true
+		}
+		if *StepSummary {
+			Config.StepSummary = true
+		}
+
+		Config.Resume = checkpoint
+		switch {
+		case *Checkpoint == "none":
+			Config.CheckpointPath = ""
+		case *Checkpoint != "" && env != "":
+			ext := filepath.Ext(*Checkpoint)
+			Config.CheckpointPath = fmt.Sprintf("%v_%v%v", strings.TrimSuffix(*Checkpoint, ext), env, ext)
+		case *Checkpoint != "":
+			Config.CheckpointPath = *Checkpoint
+		case *Resume != "":
+			Config.CheckpointPath = *Resume
+		default:
+			Config.CheckpointPath = Config.ReportName + "_checkpoint.json"
+		}
+
+		var err error
+		if Config.Include, err = process.ParseSelectors(*Include); err != nil {
+			return fmt.Errorf("failed to parse --include selectors: %s", err)
+		}
+		if Config.Exclude, err = process.ParseSelectors(*Exclude); err != nil {
+			return fmt.Errorf("failed to parse --exclude selectors: %s", err)
+		}
+
+		if *Concurrency > 0 {
+			Config.Concurrency = *Concurrency
+		}
+		if Config.Concurrency < 1 {
+			Config.Concurrency = 1
+		}
+
+		if *RateLimit > 0 {
+			Config.RateLimit.Cx1.RequestsPerSecond = *RateLimit
+			Config.RateLimit.IAM.RequestsPerSecond = *RateLimit
+		}
+
+		if *Tenant != "" {
+			Config.Tenant = *Tenant
+		}
+		if *Cx1URL != "" {
+			Config.Cx1URL = *Cx1URL
+		}
+		if *IAMURL != "" {
+			Config.IAMURL = *IAMURL
+		}
+
+		Config.Engines = process.ParseEngines(*Engines)
+		Config.HandleInterrupts = true
+		return nil
+	}
+
+	if err = applySettings(&Config, ""); err != nil {
+		logger.Errorf("%s", err)
+		return EXIT_CONFIG
 	}
-	if Config.ReportName == "" {
-		Config.ReportName = "cx1e2e_result"
+
+	if *Plan {
+		if errorCount := Config.WritePlan(os.Stdout, Config.GetPlan()); errorCount > 0 {
+			logger.Errorf("The test configuration contains %d invalid tests", errorCount)
+			return EXIT_CONFIG
+		}
+		return EXIT_PASS
 	}
 
-	if *ReportType != "" {
-		Config.ReportType = strings.ToLower(*ReportType)
+	ctx := context.Background()
+	if *RunTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *RunTimeout)
+		defer cancel()
 	}
-	if Config.ReportType == "" {
-		Config.ReportType = "html,json"
-	} else {
-		if Config.ReportType != "html" && Config.ReportType != "json" && Config.ReportType != "html,json" {
-			logger.Errorf("Supplied report type (%v) is invalid, using default", Config.ReportType)
-			Config.ReportType = "html,json"
+
+	if *Soak > 0 {
+		if len(Config.Environments) > 0 || checkpoint != nil || *Load {
+			logger.Errorf("Soak-testing mode can not be used with Environments, --resume or --load")
+			return EXIT_CONFIG
+		}
+		if err := process.ValidateLoadReportType(Config.ReportType); err != nil {
+			logger.Errorf("Invalid report type (%v): %s", Config.ReportType, err)
+			return EXIT_CONFIG
+		}
+
+		cx1client, err := connect(logger, &Config, *APIKey, *ClientID, *ClientSecret)
+		if err != nil {
+			logger.Errorf("%s", err)
+			return EXIT_AUTH
+		}
+
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		resultFile := fmt.Sprintf("%v_soak.jsonl", Config.ReportName)
+		report, err := process.RunSoak(ctx, cx1client, logger, &Config, options, *Soak, resultFile, func(c *process.TestConfig) error {
+			return applySettings(c, "")
+		})
+		if err != nil {
+			logger.Errorf("%s", err)
+			if report == nil {
+				return EXIT_FAIL
+			}
+		}
+		return getExitCode(report.GetStatus(Config.FailOnSlow), report.Settings.Aborted, report.QualityGate)
+	}
+
+	if *Load {
+		if len(Config.Environments) > 0 || checkpoint != nil {
+			logger.Errorf("Load-testing mode can not be used with Environments or --resume")
+			return EXIT_CONFIG
+		}
+		if err := process.ValidateLoadReportType(Config.ReportType); err != nil {
+			logger.Errorf("Invalid report type (%v): %s", Config.ReportType, err)
+			return EXIT_CONFIG
+		}
+
+		cx1client, err := connect(logger, &Config, *APIKey, *ClientID, *ClientSecret)
+		if err != nil {
+			logger.Errorf("%s", err)
+			return EXIT_AUTH
+		}
+
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		load := process.LoadOptions{Workers: *LoadWorkers, Iterations: *LoadIterations, Duration: *LoadDuration}
+		report, err := process.RunLoad(ctx, cx1client, logger, &Config, options, load, func(c *process.TestConfig) error {
+			return applySettings(c, "")
+		})
+		if err != nil {
+			logger.Errorf("%s", err)
+			if report == nil {
+				return EXIT_FAIL
+			}
+		}
+		return getExitCode(report.GetStatus(Config.FailOnSlow), report.Settings.Aborted, report.QualityGate)
+	}
+
+	if len(Config.Environments) > 0 {
+		if checkpoint != nil {
+			logger.Errorf("A run against multiple Environments can not be resumed, use --resume with a configuration for a single environment")
+			return EXIT_CONFIG
+		}
+		parallel := Config.ParallelEnvironments || *ParallelEnvironments
+
+		// the first interrupt stops the environments which did not start yet, each run handles it for its own tests
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
+		defer stop()
+
+		results := make([]process.EnvironmentResult, len(Config.Environments))
+		runEnv := func(id int) {
+			env := Config.Environments[id]
+			results[id] = runEnvironment(ctx, newLogger(logger.GetLevel(), env.Name), *testConfig, options, env, applySettings, *APIKey, *ClientID, *ClientSecret)
+		}
+
+		if parallel {
+			logger.Infof("Running against %d environments in parallel", len(Config.Environments))
+			var wg sync.WaitGroup
+			for id := range Config.Environments {
+				wg.Add(1)
+				go func(id int) {
+					defer wg.Done()
+					runEnv(id)
+				}(id)
+			}
+			wg.Wait()
+		} else {
+			for id, env := range Config.Environments {
+				if ctx.Err() != nil {
+					results[id] = process.EnvironmentResult{Environment: env, Error: "the run was interrupted"}
+					continue
+				}
+				logger.Infof("Running against environment %v", env.Name)
+				runEnv(id)
+			}
+		}
+
+		status, err := process.GenerateEnvironmentReport(results, logger, *testConfig, Config.ReportType, Config.ReportName, Config.FailOnSlow)
+		if err != nil {
+			logger.Errorf("%s", err)
+		}
+
+		// the gate passes if it passed in every environment, an environment which did not run can not pass it
+		interrupted := ctx.Err() != nil
+		var gate *process.QualityGateResult
+		if Config.QualityGate != nil {
+			gate = &process.QualityGateResult{Passed: true}
+		}
+		for _, r := range results {
+			if r.Result != nil {
+				interrupted = interrupted || r.Result.Interrupted
+			}
+			if gate != nil && (r.Result == nil || r.Result.Report.QualityGate == nil || !r.Result.Report.QualityGate.Passed) {
+				gate.Passed = false
+			}
+		}
+		return getExitCode(status, interrupted, gate)
+	}
+
+	cx1client, err := connect(logger, &Config, *APIKey, *ClientID, *ClientSecret)
+	if err != nil {
+		logger.Errorf("%s", err)
+		return EXIT_AUTH
+	}
+
+	result, err := process.Execute(ctx, cx1client, logger, &Config)
+	if err != nil {
+		logger.Errorf("%s", err)
+		if result == nil {
+			return EXIT_FAIL
 		}
 	}
+	return getExitCode(result.Status, result.Interrupted, result.Report.QualityGate)
+}
+
+func newLogger(level logrus.Level, prefix string) *logrus.Logger {
+	logger := logrus.New()
+	logger.SetLevel(level)
+	myformatter := &easy.Formatter{}
+	myformatter.TimestampFormat = "2006-01-02 15:04:05.000"
+	if prefix == "" {
+		myformatter.LogFormat = "[%lvl%][%time%] %msg%\n"
+	} else {
+		myformatter.LogFormat = fmt.Sprintf("[%%lvl%%][%%time%%][%v] %%msg%%\n", prefix)
+	}
+	logger.SetFormatter(myformatter)
+	logger.SetOutput(os.Stdout)
+	return logger
+}
 
+// creates an authenticated client for the target of the configuration and fills in the user and version details
+func connect(logger *logrus.Logger, Config *process.TestConfig, APIKey, ClientID, ClientSecret string) (*Cx1ClientGo.Cx1Client, error) {
 	var cx1client *Cx1ClientGo.Cx1Client
+	var err error
 	httpClient := &http.Client{}
 
 	if Config.ProxyURL != "" {
 		proxyURL, err := url.Parse(Config.ProxyURL)
 		if err != nil {
-			logger.Fatalf("Failed to parse specified proxy address %v: %s", Config.ProxyURL, err)
-			return 0
+			return nil, fmt.Errorf("failed to parse specified proxy address %v: %s", Config.ProxyURL, err)
 		}
 		transport := &http.Transport{}
 		transport.Proxy = http.ProxyURL(proxyURL)
@@ -127,33 +432,28 @@ func run() float32 {
 		logger.Infof("Running with proxy: %v", Config.ProxyURL)
 	}
 
-	if *Tenant != "" {
-		Config.Tenant = *Tenant
-	}
-	if *Cx1URL != "" {
-		Config.Cx1URL = *Cx1URL
-	}
-	if *IAMURL != "" {
-		Config.IAMURL = *IAMURL
+	Config.RateLimiter, err = process.NewRateLimiter(httpClient.Transport, Config.RateLimit, Config.Cx1URL, Config.IAMURL, logger)
+	if err != nil {
+		return nil, err
 	}
+	httpClient.Transport = Config.RateLimiter
 
-	if *APIKey != "" {
-		cx1client, err = Cx1ClientGo.NewAPIKeyClient(httpClient, Config.Cx1URL, Config.IAMURL, Config.Tenant, *APIKey, logger)
-		Config.AuthType = fmt.Sprintf("APIKey %v", Cx1ClientGo.ShortenGUID(*APIKey))
+	if APIKey != "" {
+		cx1client, err = Cx1ClientGo.NewAPIKeyClient(httpClient, Config.Cx1URL, Config.IAMURL, Config.Tenant, APIKey, logger)
+		Config.AuthType = fmt.Sprintf("APIKey %v", Cx1ClientGo.ShortenGUID(APIKey))
 	} else {
-		cx1client, err = Cx1ClientGo.NewOAuthClient(httpClient, Config.Cx1URL, Config.IAMURL, Config.Tenant, *ClientID, *ClientSecret, logger)
-		Config.AuthType = fmt.Sprintf("OAuth client %v", *ClientID)
+		cx1client, err = Cx1ClientGo.NewOAuthClient(httpClient, Config.Cx1URL, Config.IAMURL, Config.Tenant, ClientID, ClientSecret, logger)
+		Config.AuthType = fmt.Sprintf("OAuth client %v", ClientID)
 	}
 
 	if err != nil {
-		logger.Fatalf("Failed to create Cx1 client: %s", err)
-		return 0
+		return nil, fmt.Errorf("failed to create Cx1 client: %s", err)
 	}
 
 	logger.Infof("Created Cx1 client %s", cx1client.String())
 	currentUser, err := cx1client.Whoami()
 	if err != nil {
-		logger.Fatalf("Failed to get cx1 client current user: %s", err)
+		return nil, fmt.Errorf("failed to get cx1 client current user: %s", err)
 	}
 	Config.AuthUser = currentUser.String()
 	Config.EnvironmentVersion, err = cx1client.GetVersion()
@@ -161,20 +461,56 @@ func run() float32 {
 		logger.Errorf("Failed to get version info: %s", err)
 	}
 	logger.Infof("Cx1 version: %v", Config.EnvironmentVersion.String())
+	return cx1client, nil
+}
 
-	EngineList := strings.Split(strings.ToLower(*Engines), ",")
-	for _, e := range EngineList {
-		switch e {
-		case "sast":
-			Config.Engines.SAST = true
-		case "sca":
-			Config.Engines.SCA = true
-		case "kics":
-			Config.Engines.KICS = true
-		case "apisec":
-			Config.Engines.APISEC = true
-		}
+// loads the configuration again for the environment so that runs do not share state, and runs it
+func runEnvironment(ctx context.Context, logger *logrus.Logger, configPath string, options process.ConfigOptions, env process.Environment, applySettings func(*process.TestConfig, string) error, APIKey, ClientID, ClientSecret string) process.EnvironmentResult {
+	result := process.EnvironmentResult{
+		Environment: env,
+		Target:      fmt.Sprintf("%v tenant %v", env.Cx1URL, env.Tenant),
 	}
 
-	return process.RunTests(cx1client, logger, &Config)
+	Config, err := process.LoadConfig(logger, configPath, options)
+	if err == nil {
+		err = applySettings(&Config, env.Name)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	Config.SetEnvironment(env)
+
+	envAPIKey, envClientID, envClientSecret, err := env.GetCredentials()
+	if err != nil {
+		logger.Errorf("%s", err)
+		result.Error = err.Error()
+		return result
+	}
+	if envAPIKey != "" || envClientID != "" {
+		APIKey, ClientID, ClientSecret = envAPIKey, envClientID, envClientSecret
+	}
+	if APIKey == "" && (ClientID == "" || ClientSecret == "") {
+		result.Error = "authentication (API Key or client+secret) not provided"
+		logger.Errorf("Environment %v: %v", env.Name, result.Error)
+		return result
+	}
+
+	cx1client, err := connect(logger, &Config, APIKey, ClientID, ClientSecret)
+	if err != nil {
+		logger.Errorf("%s", err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Version = Config.EnvironmentVersion
+
+	run, err := process.Execute(ctx, cx1client, logger, &Config)
+	if err != nil {
+		logger.Errorf("%s", err)
+	}
+	result.Result = run
+	if run == nil && err != nil {
+		result.Error = err.Error()
+	}
+	return result
 }
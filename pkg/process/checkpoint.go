@@ -0,0 +1,192 @@
+package process
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// the progress of a run, written after every test so that an interrupted run can be continued with --resume
+type Checkpoint struct {
+	ConfigPath string
+	E2ESuffix  string
+	Timestamp  string
+	Tests      map[string]CheckpointTest // test key -> completed operations
+	Created    []types.CreatedObject
+	Variables  map[string]string
+}
+
+type CheckpointTest struct {
+	Results map[string]TestResult // CRUD -> result
+	State   []byte                // gob-encoded test, including the objects created in or read from Cx1
+}
+
+// writes the checkpoint file and restores the tests of a resumed run
+type checkpointWriter struct {
+	lock       sync.Mutex
+	path       string
+	checkpoint Checkpoint
+	testKeys   map[TestRunner]string
+}
+
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoint Checkpoint
+	if err = json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %v: %s", path, err)
+	}
+	return &checkpoint, nil
+}
+
+// identifies a test by its position in the configuration, which is the same when the same configuration is loaded again
+func (c *TestConfig) getTestKeys() map[TestRunner]string {
+	keys := make(map[TestRunner]string)
+	for setID := range c.Tests {
+		set := &c.Tests[setID]
+		for _, phase := range testPhases {
+			prefix := fmt.Sprintf("%d:%v/", setID, set.Name)
+			if phase != PHASE_TEST {
+				prefix += phase + "/"
+			}
+			for _, tests := range set.GetPhaseTests(phase) {
+				for testID, test := range tests {
+					keys[test] = fmt.Sprintf("%v%v#%d", prefix, test.GetModule(), testID)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+func newCheckpointWriter(Config *TestConfig) *checkpointWriter {
+	return &checkpointWriter{
+		path: Config.CheckpointPath,
+		checkpoint: Checkpoint{
+			ConfigPath: Config.ConfigPath,
+			E2ESuffix:  os.Getenv("E2E_RUN_SUFFIX"),
+			Tests:      make(map[string]CheckpointTest),
+		},
+		testKeys: Config.getTestKeys(),
+	}
+}
+
+// restores the tests, created objects and variables from a previous run
+func (w *checkpointWriter) Restore(logger *logrus.Logger, previous *Checkpoint, state *runState) {
+	if previous.ConfigPath != w.checkpoint.ConfigPath {
+		logger.Warnf("Resuming a run of %v with configuration %v", previous.ConfigPath, w.checkpoint.ConfigPath)
+	}
+
+	restored := 0
+	for test, key := range w.testKeys {
+		completed, ok := previous.Tests[key]
+		if !ok {
+			continue
+		}
+		if err := gob.NewDecoder(bytes.NewReader(completed.State)).Decode(test); err != nil {
+			logger.Errorf("Failed to restore the state of %v %v from the checkpoint, it will run again: %s", test.GetModule(), test.String(), err)
+			continue
+		}
+		w.checkpoint.Tests[key] = completed
+		restored++
+	}
+	if restored < len(previous.Tests) {
+		logger.Warnf("%d tests from the checkpoint do not match a test in the configuration and will be ignored", len(previous.Tests)-restored)
+	}
+
+	for _, object := range previous.Created {
+		state.Created.Add(object)
+	}
+	for name, value := range previous.Variables {
+		state.SetVariable(name, value)
+	}
+	logger.Infof("Resuming the run from %v with %d completed tests", previous.Timestamp, restored)
+}
+
+// returns the result of the operation if it was already completed before the run was resumed
+func (w *checkpointWriter) GetResult(test TestRunner, CRUD string) (TestResult, bool) {
+	if w == nil {
+		return TestResult{}, false
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	result, ok := w.checkpoint.Tests[w.testKeys[test]].Results[CRUD]
+	return result, ok
+}
+
+// is every operation of every test in the set completed
+func (w *checkpointWriter) IsSetCompleted(set *TestSet) bool {
+	for _, CRUD := range []string{types.OP_CREATE, types.OP_READ, types.OP_UPDATE, types.OP_DELETE} {
+		for _, tests := range set.GetAllModuleTests() {
+			for _, test := range tests {
+				if _, ok := w.GetResult(test, CRUD); test.IsType(CRUD) && !ok {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// records the completed operation and writes the checkpoint file, must be called from the goroutine which ran the test
+func (w *checkpointWriter) Save(logger *logrus.Logger, test TestRunner, result TestResult, state *runState) {
+	if w == nil || w.path == "" {
+		return
+	}
+
+	var testState bytes.Buffer
+	if err := gob.NewEncoder(&testState).Encode(test); err != nil {
+		logger.Errorf("Failed to save the state of %v %v in the checkpoint: %s", test.GetModule(), test.String(), err)
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	key := w.testKeys[test]
+	completed := w.checkpoint.Tests[key]
+	if completed.Results == nil {
+		completed.Results = make(map[string]TestResult)
+	}
+	completed.Results[result.CRUD] = result
+	completed.State = testState.Bytes()
+	w.checkpoint.Tests[key] = completed
+
+	w.checkpoint.Timestamp = time.Now().Format("2006-01-02 15:04:05")
+	w.checkpoint.Created = state.Created.GetObjects()
+	w.checkpoint.Variables = state.GetVariables()
+
+	data, err := json.Marshal(w.checkpoint)
+	if err != nil {
+		logger.Errorf("Failed to write checkpoint %v: %s", w.path, err)
+		return
+	}
+
+	// write to a temporary file first so that the previous checkpoint survives if the process dies while writing
+	if err = os.WriteFile(w.path+".tmp", data, 0600); err == nil {
+		err = os.Rename(w.path+".tmp", w.path)
+	}
+	if err != nil {
+		logger.Errorf("Failed to write checkpoint %v: %s", w.path, err)
+	}
+}
+
+// removes the checkpoint file once the run has completed
+func (w *checkpointWriter) Remove(logger *logrus.Logger) {
+	if w == nil || w.path == "" {
+		return
+	}
+	if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+		logger.Warnf("Failed to remove checkpoint %v: %s", w.path, err)
+	}
+}
@@ -0,0 +1,155 @@
+package process
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+const checkpointConfig = `Tests:
+  - Name: objects
+    Projects:
+      - Name: e2e-project
+        Test: CR
+        Expect:
+          - Field: Project.Name
+            Equals: e2e-project
+    Scans:
+      - Project: e2e-project
+        Branch: main
+        Repository: https://github.com/example/repo
+        Test: CR
+    Queries:
+      - Language: Java
+        Group: Java_High_Risk
+        Name: SQL_Injection
+        Scope: { Project: e2e-project }
+        Test: C
+  - Name: later
+    Groups:
+      - Name: e2e-group
+        Test: C
+`
+
+func TestCheckpointRoundTrip(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte(checkpointConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	load := func() *TestConfig {
+		conf, err := LoadConfig(logger, configPath, ConfigOptions{})
+		if err != nil {
+			t.Fatalf("failed to load the configuration: %s", err)
+		}
+		conf.CheckpointPath = filepath.Join(dir, "checkpoint.json")
+		conf.startRun(nil)
+		return &conf
+	}
+
+	// the first run completes the tests of the first set and is then interrupted
+	first := load()
+	set := &first.Tests[0]
+	project := set.Modules["Projects"][0].(*types.ProjectCRUD)
+	scan := set.Modules["Scans"][0].(*types.ScanCRUD)
+	query := set.Modules["Queries"][0].(*types.CxQLCRUD)
+
+	project.Project = &Cx1ClientGo.Project{ProjectID: "project-id", Name: "e2e-project"}
+	scan.Scan = &Cx1ClientGo.Scan{ScanID: "scan-id", Status: "Completed", ProjectID: "project-id"}
+	query.Query = &Cx1ClientGo.AuditQuery{Level: "Project", LevelID: "project-id", Name: "SQL_Injection"}
+	query.ScopeID = "project-id"
+
+	first.state.Created.Add(types.CreatedObject{Module: types.MOD_PROJECT, ID: "project-id", Name: "e2e-project"})
+	first.state.SetVariable("projectId", "project-id")
+	for _, test := range []struct {
+		test TestRunner
+		CRUD string
+	}{{project, types.OP_CREATE}, {project, types.OP_READ}, {scan, types.OP_CREATE}, {scan, types.OP_READ}, {query, types.OP_CREATE}} {
+		result := TestResult{Result: TST_PASS, CRUD: test.CRUD, Module: test.test.GetModule(), Name: set.Name, Duration: 1.5}
+		first.state.checkpoint.Save(logger, test.test, result, first.state)
+	}
+
+	checkpoint, err := LoadCheckpoint(first.CheckpointPath)
+	if err != nil {
+		t.Fatalf("failed to load the checkpoint: %s", err)
+	}
+	if len(checkpoint.Tests) != 3 {
+		t.Errorf("expected 3 tests in the checkpoint, got %d", len(checkpoint.Tests))
+	}
+
+	// the resumed run loads the configuration again
+	resumed := load()
+	resumed.Resume = checkpoint
+	resumed.state.checkpoint.Restore(logger, checkpoint, resumed.state)
+
+	set = &resumed.Tests[0]
+	project = set.Modules["Projects"][0].(*types.ProjectCRUD)
+	scan = set.Modules["Scans"][0].(*types.ScanCRUD)
+	query = set.Modules["Queries"][0].(*types.CxQLCRUD)
+
+	if project.Project == nil || project.Project.ProjectID != "project-id" {
+		t.Errorf("expected the project to be restored, got %v", project.Project)
+	}
+	if len(project.Expect) != 1 || project.Expect[0].Equals == nil || *project.Expect[0].Equals != "e2e-project" {
+		t.Errorf("expected the assertions of the project test to be kept, got %v", project.Expect)
+	}
+	if scan.Scan == nil || scan.Scan.ScanID != "scan-id" || scan.Scan.Status != "Completed" {
+		t.Errorf("expected the scan to be restored, got %v", scan.Scan)
+	}
+	if query.Query == nil || query.Query.LevelID != "project-id" || query.ScopeID != "project-id" {
+		t.Errorf("expected the query to be restored, got %v", query.Query)
+	}
+
+	result, ok := resumed.state.checkpoint.GetResult(scan, types.OP_READ)
+	if !ok || result.Result != TST_PASS || result.Duration != 1.5 {
+		t.Errorf("expected the result of the scan read to be restored, got %v %v", ok, result)
+	}
+	if _, ok := resumed.state.checkpoint.GetResult(resumed.Tests[1].Modules["Groups"][0], types.OP_CREATE); ok {
+		t.Errorf("expected no result for a test which did not run")
+	}
+	if !resumed.state.checkpoint.IsSetCompleted(&resumed.Tests[0]) {
+		t.Errorf("expected the first set to be completed")
+	}
+	if resumed.state.checkpoint.IsSetCompleted(&resumed.Tests[1]) {
+		t.Errorf("expected the second set to not be completed")
+	}
+
+	if objects := resumed.state.Created.GetObjects(); len(objects) != 1 || objects[0].ID != "project-id" {
+		t.Errorf("expected the created objects to be restored, got %v", objects)
+	}
+	if value, ok := resumed.state.GetVariable("projectId"); !ok || value != "project-id" {
+		t.Errorf("expected the variables to be restored, got %v", value)
+	}
+}
+
+func TestGetTestKeys(t *testing.T) {
+	group := func() TestRunner { return &types.GroupCRUD{} }
+	config := TestConfig{Tests: []TestSet{
+		{Name: "a", Modules: map[string][]TestRunner{"Groups": {group(), group()}}},
+		{Name: "b", Modules: map[string][]TestRunner{"Groups": {group()}}, Setup: &TestSection{Modules: map[string][]TestRunner{"Groups": {group()}}}},
+	}}
+
+	keys := config.getTestKeys()
+	expected := map[TestRunner]string{
+		config.Tests[0].Modules["Groups"][0]:       "0:a/" + types.MOD_GROUP + "#0",
+		config.Tests[0].Modules["Groups"][1]:       "0:a/" + types.MOD_GROUP + "#1",
+		config.Tests[1].Modules["Groups"][0]:       "1:b/" + types.MOD_GROUP + "#0",
+		config.Tests[1].Setup.Modules["Groups"][0]: "1:b/Setup/" + types.MOD_GROUP + "#0",
+	}
+	if len(keys) != len(expected) {
+		t.Errorf("expected %d keys, got %v", len(expected), keys)
+	}
+	for test, key := range expected {
+		if keys[test] != key {
+			t.Errorf("expected key %v, got %v", key, keys[test])
+		}
+	}
+}
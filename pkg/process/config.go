@@ -5,14 +5,146 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/cxpsemea/cx1e2e/pkg/types"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
 )
 
-func LoadConfig(logger *logrus.Logger, configPath string) (TestConfig, error) {
+// options which change how a test config is loaded
+type ConfigOptions struct {
+	Lenient   bool   // ignore unknown keys, as older versions of cx1e2e did
+	RunSuffix string // used instead of the E2E_RUN_SUFFIX environment variable, eg: to give each load test iteration its own object names
+}
+
+func LoadConfig(logger *logrus.Logger, configPath string, options ConfigOptions) (TestConfig, error) {
+	conf, err := loadConfigFile(logger, configPath, options)
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.validateRetryPolicies()
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.validateCaptures()
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.validateExpectations()
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.validateDurations()
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.validateHooks()
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.validateEnvironments()
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.validateRateLimits()
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.validateQualityGate()
+	if err != nil {
+		return conf, err
+	}
+
+	err = conf.sortTests()
+	return conf, err
+}
+
+func (c *TestConfig) validateRetryPolicies() error {
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			return fmt.Errorf("config retry policy: %s", err)
+		}
+	}
+	for id := range c.Tests {
+		set := &c.Tests[id]
+		if set.Retry != nil {
+			if err := set.Retry.Validate(); err != nil {
+				return fmt.Errorf("test set '%v' retry policy: %s", set.Name, err)
+			}
+		}
+		for _, tests := range set.GetAllModuleTests() {
+			for _, test := range tests {
+				if test.GetRetry() != nil {
+					if err := test.GetRetry().Validate(); err != nil {
+						return fmt.Errorf("test %v in test set '%v' retry policy: %s", test.String(), set.Name, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *TestConfig) validateCaptures() error {
+	for _, set := range c.Tests {
+		for _, tests := range set.GetAllModuleTests() {
+			for _, test := range tests {
+				captures := test.GetCaptures()
+				names := make([]string, 0, len(captures))
+				for name := range captures {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					path := captures[name]
+					if !types.VariableName.MatchString(name) {
+						return fmt.Errorf("test %v in test set '%v' captures into invalid variable name '%v'", test.String(), set.Name, name)
+					}
+					if err := types.CheckCapturePath(reflect.TypeOf(test), path); err != nil {
+						return fmt.Errorf("test %v in test set '%v' can not capture %v: %s", test.String(), set.Name, name, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *TestConfig) validateExpectations() error {
+	for _, set := range c.Tests {
+		for _, tests := range set.GetAllModuleTests() {
+			for _, test := range tests {
+				if len(test.GetExpectations()) == 0 {
+					continue
+				}
+				if !test.IsType(types.OP_READ) {
+					return fmt.Errorf("test %v in test set '%v' has Expect assertions but no [R]ead test", test.String(), set.Name)
+				}
+				for _, assertion := range test.GetExpectations() {
+					if err := assertion.Validate(reflect.TypeOf(test)); err != nil {
+						return fmt.Errorf("test %v in test set '%v': %s", test.String(), set.Name, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func loadConfigFile(logger *logrus.Logger, configPath string, options ConfigOptions) (TestConfig, error) {
 	var conf TestConfig
 
 	file, err := os.Open(configPath)
@@ -33,110 +165,281 @@ func LoadConfig(logger *logrus.Logger, configPath string) (TestConfig, error) {
 	re := regexp.MustCompile(`%([0-9a-zA-Z_]+)%`)
 	fileContents := string(fileBytes)
 	for matches := re.FindStringSubmatch(fileContents); len(matches) > 0; matches = re.FindStringSubmatch(fileContents) {
-		fileContents = strings.ReplaceAll(fileContents, fmt.Sprintf("%%%v%%", matches[1]), os.Getenv(matches[1]))
+		value := os.Getenv(matches[1])
+		if matches[1] == "E2E_RUN_SUFFIX" && options.RunSuffix != "" {
+			value = options.RunSuffix
+		}
+		fileContents = strings.ReplaceAll(fileContents, fmt.Sprintf("%%%v%%", matches[1]), value)
 	}
 
 	d := yaml.NewDecoder(strings.NewReader(fileContents))
+	d.SetStrict(!options.Lenient)
 
 	err = d.Decode(&conf)
 	if err != nil {
+		return conf, getDecodeError(configPath, string(fileBytes), err)
+	}
+	conf.usesRunSuffix = strings.Contains(string(fileBytes), "%E2E_RUN_SUFFIX%")
+
+	if err = conf.validateTestTypes(configPath); err != nil {
 		return conf, err
 	}
 
 	testSet := make([]TestSet, 0)
 
 	// propagate the filename to sub-tests
-	// TODO: refactor this to use generics?
-	for id := range conf.Tests {
-		for id2 := range conf.Tests[id].AccessAssignments {
-			conf.Tests[id].AccessAssignments[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Applications {
-			conf.Tests[id].Applications[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Flags {
-			conf.Tests[id].Flags[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Groups {
-			conf.Tests[id].Groups[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Imports {
-			conf.Tests[id].Imports[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Presets {
-			conf.Tests[id].Presets[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Projects {
-			conf.Tests[id].Projects[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Queries {
-			conf.Tests[id].Queries[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Reports {
-			conf.Tests[id].Reports[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Results {
-			conf.Tests[id].Results[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Roles {
-			conf.Tests[id].Roles[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Scans {
-			conf.Tests[id].Scans[id2].TestSource = configPath
-		}
-		for id2 := range conf.Tests[id].Users {
-			conf.Tests[id].Users[id2].TestSource = configPath
+	for _, set := range conf.Tests {
+		for _, tests := range set.GetAllModuleTests() {
+			for _, test := range tests {
+				test.SetSource(configPath)
+			}
 		}
 	}
 
 	for _, set := range conf.Tests {
 		logger.Tracef("Checking TestSet %v for file references", set.Name)
 		if set.File != "" {
+			if err := set.validateInclude(); err != nil {
+				return conf, fmt.Errorf("%v: %s", configPath, err)
+			}
 			configPath, err := getFilePath(currentRoot, set.File)
 			if err != nil {
 				return conf, err
 			}
 
-			conf2, err := LoadConfig(logger, configPath)
+			conf2, err := loadConfigFile(logger, configPath, options)
 			if err != nil {
 				return conf, fmt.Errorf("error loading sub-test %v: %s", set.File, err)
 			}
+			if !conf2.Hooks.IsEmpty() {
+				return conf, fmt.Errorf("error loading sub-test %v: Hooks can only be defined in the main configuration or in test sets", set.File)
+			}
+			if len(conf2.Environments) > 0 {
+				return conf, fmt.Errorf("error loading sub-test %v: Environments can only be defined in the main configuration", set.File)
+			}
 			logger.Debugf("Loaded sub-config from %v", conf2.ConfigPath)
+			conf.usesRunSuffix = conf.usesRunSuffix || conf2.usesRunSuffix
 			testSet = append(testSet, conf2.Tests...)
 		} else {
-			for id, scan := range set.Scans {
-				logger.Tracef(" - Checking Scan TestSet %v for file references", set.Name)
-				if scan.ZipFile != "" {
-					filePath, err := getFilePath(currentRoot, scan.ZipFile)
-					if err != nil {
-						return conf, fmt.Errorf("error locating scan zipfile %v", scan.ZipFile)
-					}
-					set.Scans[id].ZipFile = filePath
+			instances, err := set.ExpandMatrix()
+			if err != nil {
+				return conf, fmt.Errorf("%v: %s", configPath, err)
+			}
+			for _, instance := range instances {
+				if err = resolveFilePaths(logger, currentRoot, &instance); err != nil {
+					return conf, err
 				}
+				testSet = append(testSet, instance)
 			}
-			for id, imp := range set.Imports {
-				logger.Tracef(" - Checking Import TestSet %v for file references", set.Name)
-				if imp.ZipFile != "" {
-					filePath, err := getFilePath(currentRoot, imp.ZipFile)
-					if err != nil {
-						return conf, fmt.Errorf("error locating import zipfile %v", imp.ZipFile)
-					}
-					set.Imports[id].ZipFile = filePath
+		}
+	}
+	conf.Tests = testSet
+
+	return conf, nil
+}
+
+// the sets of an included file are used as they are, so settings of the set which includes them would be lost
+func (t *TestSet) validateInclude() error {
+	settings := []string{}
+	if len(t.DependsOn) > 0 {
+		settings = append(settings, "DependsOn")
+	}
+	if t.Parallel {
+		settings = append(settings, "Parallel")
+	}
+	if t.Retry != nil {
+		settings = append(settings, "Retry")
+	}
+	if len(t.Tags) > 0 {
+		settings = append(settings, "Tags")
+	}
+	if len(t.Matrix) > 0 {
+		settings = append(settings, "Matrix")
+	}
+	if t.Hooks != nil {
+		settings = append(settings, "Hooks")
+	}
+	if t.Setup != nil {
+		settings = append(settings, "Setup")
+	}
+	if t.Teardown != nil {
+		settings = append(settings, "Teardown")
+	}
+	if len(settings) > 0 {
+		return fmt.Errorf("test set '%v' includes %v and can not also define %v, set them on the test sets of the included file instead", t.Name, t.File, strings.Join(settings, ", "))
+	}
+	return nil
+}
+
+// makes the files referenced by the tests, eg: zip files, relative to the config file
+func resolveFilePaths(logger *logrus.Logger, currentRoot string, set *TestSet) error {
+	locate := func(file string) (string, error) {
+		return getFilePath(currentRoot, file)
+	}
+	for _, tests := range set.GetAllModuleTests() {
+		for _, test := range tests {
+			if referencer, ok := test.(FileReferencer); ok {
+				logger.Tracef(" - Checking %v test %v in TestSet %v for file references", test.GetModule(), test.String(), set.Name)
+				if err := referencer.ResolveFiles(locate); err != nil {
+					return err
 				}
-				if imp.ProjectMapFile != "" {
-					filePath, err := getFilePath(currentRoot, imp.ProjectMapFile)
-					if err != nil {
-						return conf, fmt.Errorf("error locating import ProjectMapFile %v", imp.ProjectMapFile)
+			}
+		}
+	}
+	return nil
+}
+
+// orders the test sets so that each set runs after the sets it depends on, keeping the configured order otherwise
+func (c *TestConfig) sortTests() error {
+	setIDs := make(map[string][]int)
+	for id, set := range c.Tests {
+		setIDs[set.Name] = append(setIDs[set.Name], id)
+		if set.BaseName != "" && set.BaseName != set.Name { // depending on a matrix means depending on all of its instances
+			setIDs[set.BaseName] = append(setIDs[set.BaseName], id)
+		}
+	}
+
+	dependencies := make([][]int, len(c.Tests))
+	for id := range c.Tests {
+		set := &c.Tests[id]
+		depNames := append([]string{}, set.DependsOn...)
+		for _, tests := range set.GetAllModuleTests() {
+			for _, test := range tests {
+				for _, dep := range test.GetDependencies() {
+					if set.HasName(dep) {
+						return fmt.Errorf("test %v in test set '%v' can not depend on its own test set", test.String(), set.Name)
 					}
-					set.Imports[id].ProjectMapFile = filePath
+					depNames = append(depNames, dep)
 				}
 			}
-			testSet = append(testSet, set)
+		}
+
+		for _, dep := range depNames {
+			ids, ok := setIDs[dep]
+			if !ok {
+				return fmt.Errorf("test set '%v' depends on unknown test set '%v'", set.Name, dep)
+			}
+			dependencies[id] = append(dependencies[id], ids...)
 		}
 	}
-	conf.Tests = testSet
 
-	return conf, nil
+	done := make([]bool, len(c.Tests))
+	sorted := make([]TestSet, 0, len(c.Tests))
+	for len(sorted) < len(c.Tests) {
+		next := -1
+		for id := range c.Tests {
+			if !done[id] && allDone(dependencies[id], done) {
+				next = id
+				break
+			}
+		}
+		if next == -1 {
+			return fmt.Errorf("test set dependencies contain a cycle: %v", c.findCycle(dependencies, done))
+		}
+		done[next] = true
+		sorted = append(sorted, c.Tests[next])
+	}
+
+	c.Tests = sorted
+	return nil
+}
+
+func allDone(ids []int, done []bool) bool {
+	for _, id := range ids {
+		if !done[id] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *TestConfig) findCycle(dependencies [][]int, done []bool) string {
+	visited := make([]bool, len(c.Tests))
+	var path []int
+
+	var visit func(id int) []int
+	visit = func(id int) []int {
+		for i, p := range path {
+			if p == id {
+				return append(path[i:], id)
+			}
+		}
+		if visited[id] {
+			return nil
+		}
+		visited[id] = true
+		path = append(path, id)
+		for _, dep := range dependencies[id] {
+			if !done[dep] {
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		return nil
+	}
+
+	for id := range c.Tests {
+		if !done[id] {
+			if cycle := visit(id); cycle != nil {
+				names := make([]string, len(cycle))
+				for i, setID := range cycle {
+					names[i] = fmt.Sprintf("'%v'", c.Tests[setID].Name)
+				}
+				return strings.Join(names, " -> ")
+			}
+		}
+	}
+	return "unknown"
+}
+
+var yamlLineError = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+var yamlFieldName = regexp.MustCompile(`(?:field|key) "?([^" ]+)"? (?:not found|already set)`)
+
+// rewrites yaml decoding errors as file:line:column: error, one per line
+func getDecodeError(configPath, fileContents string, err error) error {
+	messages := []string{err.Error()}
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		messages = typeErr.Errors
+	}
+
+	lines := strings.Split(fileContents, "\n")
+	for id, message := range messages {
+		matches := yamlLineError.FindStringSubmatch(message)
+		if len(matches) == 0 {
+			messages[id] = fmt.Sprintf("%v: %v", configPath, strings.TrimPrefix(message, "yaml: "))
+			continue
+		}
+
+		lineNo, _ := strconv.Atoi(matches[1])
+		column := 1
+		if lineNo >= 1 && lineNo <= len(lines) {
+			line := lines[lineNo-1]
+			column = len(line) - len(strings.TrimLeft(line, " \t-")) + 1
+			if field := yamlFieldName.FindStringSubmatch(matches[2]); len(field) > 0 {
+				if index := strings.Index(line, field[1]+":"); index >= 0 {
+					column = index + 1
+				}
+			}
+		}
+		messages[id] = fmt.Sprintf("%v:%d:%d: %v", configPath, lineNo, column, matches[2])
+	}
+	return fmt.Errorf("%v", strings.Join(messages, "\n"))
+}
+
+// checks that each test only uses the C, R, U and D test types
+func (c *TestConfig) validateTestTypes(configPath string) error {
+	for _, set := range c.Tests {
+		for _, tests := range set.GetAllModuleTests() {
+			for _, test := range tests {
+				if strings.Trim(test.GetTestType(), "CRUD") != "" {
+					return fmt.Errorf("%v: test %v in test set '%v' has invalid Test type '%v', should be a combination of C, R, U, D", configPath, test.String(), set.Name, test.GetTestType())
+				}
+			}
+		}
+	}
+	return nil
 }
 
 func getFilePath(currentRoot, file string) (string, error) {
@@ -0,0 +1,207 @@
+package process
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+func TestSortTests(t *testing.T) {
+	set := func(name string, dependsOn ...string) TestSet {
+		return TestSet{Name: name, DependsOn: dependsOn}
+	}
+	testDependsOn := func(name, dependsOn string) TestSet {
+		group := &types.GroupCRUD{}
+		group.Test = "C"
+		group.DependsOn = []string{dependsOn}
+		return TestSet{Name: name, Modules: map[string][]TestRunner{"Groups": {group}}}
+	}
+
+	tests := []struct {
+		name  string
+		sets  []TestSet
+		order []string
+		err   string
+	}{
+		{
+			name:  "no dependencies keeps the configured order",
+			sets:  []TestSet{set("a"), set("b"), set("c")},
+			order: []string{"a", "b", "c"},
+		},
+		{
+			name:  "dependency runs first",
+			sets:  []TestSet{set("a", "c"), set("b"), set("c")},
+			order: []string{"b", "c", "a"},
+		},
+		{
+			name:  "chain of dependencies",
+			sets:  []TestSet{set("a", "b"), set("b", "c"), set("c")},
+			order: []string{"c", "b", "a"},
+		},
+		{
+			name:  "dependency of a test",
+			sets:  []TestSet{testDependsOn("a", "b"), set("b")},
+			order: []string{"b", "a"},
+		},
+		{
+			name:  "dependency on a matrix waits for all instances",
+			sets:  []TestSet{set("a", "m"), {Name: "m [x=1]", BaseName: "m"}, {Name: "m [x=2]", BaseName: "m"}},
+			order: []string{"m [x=1]", "m [x=2]", "a"},
+		},
+		{
+			name: "unknown dependency",
+			sets: []TestSet{set("a", "missing")},
+			err:  "test set 'a' depends on unknown test set 'missing'",
+		},
+		{
+			name: "test depends on its own set",
+			sets: []TestSet{testDependsOn("a", "a")},
+			err:  "can not depend on its own test set",
+		},
+		{
+			name: "cycle",
+			sets: []TestSet{set("a"), set("b", "c"), set("c", "d"), set("d", "b")},
+			err:  "test set dependencies contain a cycle: 'b' -> 'c' -> 'd' -> 'b'",
+		},
+		{
+			name: "set depends on itself",
+			sets: []TestSet{set("a", "a")},
+			err:  "cycle: 'a' -> 'a'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := TestConfig{Tests: test.sets}
+			err := config.sortTests()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			order := []string{}
+			for _, set := range config.Tests {
+				order = append(order, set.Name)
+			}
+			if strings.Join(order, ",") != strings.Join(test.order, ",") {
+				t.Errorf("expected order %v, got %v", test.order, order)
+			}
+		})
+	}
+}
+
+func TestIncludeSettings(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "included.yaml"), []byte("Tests:\n  - Name: included\n    Groups:\n      - Name: g\n        Test: C\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		set  string
+		err  string
+	}{
+		{"only File", "", ""},
+		{"Wait is ignored as before", "Wait: 1", ""},
+		{"DependsOn", "DependsOn: [ other ]", "can not also define DependsOn"},
+		{"several settings", "Parallel: true\n    Tags: [ smoke ]\n    Retry: { Attempts: 2 }", "can not also define Parallel, Retry, Tags"},
+		{"Matrix", "Matrix: { x: [ 1, 2 ] }", "can not also define Matrix"},
+		{"Hooks", "Hooks: { BeforeSet: [ echo ] }", "can not also define Hooks"},
+	}
+
+	for id, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, fmt.Sprintf("main%d.yaml", id))
+			config := "Tests:\n  - Name: other\n  - Name: include\n    File: included.yaml\n    " + test.set + "\n"
+			if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			conf, err := LoadConfig(logger, path, ConfigOptions{})
+			if test.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if len(conf.Tests) != 2 || conf.Tests[1].Name != "included" {
+					t.Errorf("expected the sets of the included file, got %v", conf.Tests)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestGetDecodeError(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{
+			name: "unknown key of a test",
+			yaml: "Tests:\n  - Name: a\n    Groups:\n      - Name: g\n        Roles: [ x ]\n        Test: C\n",
+			err:  "test.yaml:5:9: field Roles not found in type types.GroupCRUD",
+		},
+		{
+			name: "unknown key of a set is reported against the set type",
+			yaml: "Tests:\n  - Name: a\n    Group: []\n",
+			err:  "test.yaml:3:5: field Group not found in type process.TestSet",
+		},
+		{
+			name: "column of the key after a list item",
+			yaml: "Tests:\n  - Nme: a\n",
+			err:  "test.yaml:2:5: field Nme not found in type process.TestSet",
+		},
+		{
+			name: "wrong type",
+			yaml: "Tests:\n  - Name: a\n    Wait: abc\n",
+			err:  "test.yaml:3:5: cannot unmarshal !!str `abc` into uint",
+		},
+		{
+			name: "one line per error",
+			yaml: "Tests:\n  - Name: a\n    Wait: abc\n    Nme: b\n",
+			err:  "test.yaml:3:5: cannot unmarshal !!str `abc` into uint\ntest.yaml:4:5: field Nme not found in type process.TestSet",
+		},
+		{
+			name: "syntax error",
+			yaml: "Tests:\n  - Name: a\n  Name: b\n",
+			err:  "test.yaml:2:5: did not find expected '-' indicator",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var conf TestConfig
+			d := yaml.NewDecoder(strings.NewReader(test.yaml))
+			d.SetStrict(true)
+			err := d.Decode(&conf)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if err = getDecodeError("test.yaml", test.yaml, err); err.Error() != test.err {
+				t.Errorf("expected:\n%v\ngot:\n%v", test.err, err)
+			}
+		})
+	}
+
+	if err := getDecodeError("test.yaml", "", fmt.Errorf("yaml: control characters are not allowed")); err.Error() != "test.yaml: control characters are not allowed" {
+		t.Errorf("expected an error without a line to name the file, got %v", err)
+	}
+}
@@ -0,0 +1,313 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// a Cx1 tenant against which the whole configuration is run
+type Environment struct {
+	Name            string `yaml:"Name"`
+	Cx1URL          string `yaml:"Cx1URL"`
+	IAMURL          string `yaml:"IAMURL"`
+	Tenant          string `yaml:"Tenant"`
+	ProxyURL        string `yaml:"ProxyURL"`
+	APIKeyEnv       string `yaml:"APIKeyEnv"`       // name of the environment variable which holds the API key
+	ClientIDEnv     string `yaml:"ClientIDEnv"`     // name of the environment variable which holds the OAuth client ID
+	ClientSecretEnv string `yaml:"ClientSecretEnv"` // name of the environment variable which holds the OAuth client secret
+	Engines         string `yaml:"Engines"`         // eg: sast,kics - default: the engines from the command-line
+}
+
+// the outcome of the run against one environment
+type EnvironmentResult struct {
+	Environment Environment
+	Target      string
+	Version     Cx1ClientGo.VersionInfo
+	Result      *RunResult // nil if the run could not start
+	Error       string
+}
+
+// combined report of the same configuration run against multiple environments
+type EnvironmentReport struct {
+	Config       string                     `json:"TestConfig"`
+	Timestamp    string                     `json:"ExecutionTime"`
+	E2ESuffix    string                     `json:"E2ESuffix"`
+	Environments []EnvironmentReportSummary `json:"Environments"`
+	Tests        []EnvironmentReportTest    `json:"Tests"`
+}
+
+type EnvironmentReportSummary struct {
+	Name        string
+	Target      string
+	Version     Cx1ClientGo.VersionInfo
+	Total       Counter
+	Teardown    Counter
+	QualityGate *QualityGateResult `json:",omitempty"`
+	Error       string             `json:",omitempty"`
+}
+
+// a test and its result in each environment, environments in which the test did not run have no result
+type EnvironmentReportTest struct {
+	Name    string
+	Test    string
+	Results map[string]ReportTestDetails // environment name -> result
+}
+
+func (c *TestConfig) validateEnvironments() error {
+	names := make(map[string]bool)
+	for _, env := range c.Environments {
+		if env.Name == "" {
+			return fmt.Errorf("environment with Cx1URL %v has no Name", env.Cx1URL)
+		}
+		if names[env.Name] {
+			return fmt.Errorf("environment name '%v' is used more than once", env.Name)
+		}
+		names[env.Name] = true
+		if env.Cx1URL == "" || env.IAMURL == "" || env.Tenant == "" {
+			return fmt.Errorf("environment '%v' must have a Cx1URL, IAMURL and Tenant", env.Name)
+		}
+		if env.APIKeyEnv != "" && (env.ClientIDEnv != "" || env.ClientSecretEnv != "") {
+			return fmt.Errorf("environment '%v' can use either APIKeyEnv or ClientIDEnv and ClientSecretEnv, not both", env.Name)
+		}
+		if (env.ClientIDEnv == "") != (env.ClientSecretEnv == "") {
+			return fmt.Errorf("environment '%v' must have both ClientIDEnv and ClientSecretEnv", env.Name)
+		}
+	}
+	return nil
+}
+
+// targets the configuration at the environment
+func (c *TestConfig) SetEnvironment(env Environment) {
+	c.Environment = env.Name
+	c.Cx1URL = env.Cx1URL
+	c.IAMURL = env.IAMURL
+	c.Tenant = env.Tenant
+	if env.ProxyURL != "" {
+		c.ProxyURL = env.ProxyURL
+	}
+	if env.Engines != "" {
+		c.Engines = ParseEngines(env.Engines)
+	}
+}
+
+// returns the API key, or the client ID and secret, from the environment variables named by the environment
+// all values are empty if the environment does not reference any credentials
+func (e Environment) GetCredentials() (apiKey, clientID, clientSecret string, err error) {
+	if e.APIKeyEnv != "" {
+		if apiKey = os.Getenv(e.APIKeyEnv); apiKey == "" {
+			return "", "", "", fmt.Errorf("environment variable %v with the API key for environment '%v' is not set", e.APIKeyEnv, e.Name)
+		}
+		return apiKey, "", "", nil
+	}
+	if e.ClientIDEnv != "" {
+		clientID, clientSecret = os.Getenv(e.ClientIDEnv), os.Getenv(e.ClientSecretEnv)
+		if clientID == "" || clientSecret == "" {
+			return "", "", "", fmt.Errorf("environment variables %v and %v with the OAuth client for environment '%v' are not set", e.ClientIDEnv, e.ClientSecretEnv, e.Name)
+		}
+	}
+	return apiKey, clientID, clientSecret, nil
+}
+
+// eg: "sast,kics" enables only the SAST and KICS engines
+func ParseEngines(engines string) types.EnabledEngines {
+	var enabled types.EnabledEngines
+	for _, e := range strings.Split(strings.ToLower(engines), ",") {
+		switch strings.TrimSpace(e) {
+		case "sast":
+			enabled.SAST = true
+		case "sca":
+			enabled.SCA = true
+		case "kics":
+			enabled.KICS = true
+		case "apisec":
+			enabled.APISEC = true
+		}
+	}
+	return enabled
+}
+
+func prepareEnvironmentReport(configPath string, results []EnvironmentResult) EnvironmentReport {
+	report := EnvironmentReport{
+		Config:    configPath,
+		Timestamp: time.Now().String(),
+		E2ESuffix: os.Getenv("E2E_RUN_SUFFIX"),
+	}
+
+	rows := make(map[string]int)
+	for _, env := range results {
+		summary := EnvironmentReportSummary{
+			Name:    env.Environment.Name,
+			Target:  env.Target,
+			Version: env.Version,
+			Error:   env.Error,
+		}
+		if env.Result != nil {
+			summary.Total = env.Result.Report.Summary.Total
+			summary.Teardown = env.Result.Report.Summary.Teardown
+			summary.QualityGate = env.Result.Report.QualityGate
+			for _, details := range append(append([]ReportTestDetails{}, env.Result.Report.Details...), env.Result.Report.Teardown...) {
+				key := details.Name + "\x00" + details.Test
+				id, ok := rows[key]
+				if !ok {
+					id = len(report.Tests)
+					rows[key] = id
+					report.Tests = append(report.Tests, EnvironmentReportTest{Name: details.Name, Test: details.Test, Results: make(map[string]ReportTestDetails)})
+				}
+				report.Tests[id].Results[env.Environment.Name] = details
+			}
+		}
+		report.Environments = append(report.Environments, summary)
+	}
+	return report
+}
+
+// writes the combined report and returns the share of tests which passed across all environments, slow tests count as passed unless slowFails is set
+func GenerateEnvironmentReport(results []EnvironmentResult, logger *logrus.Logger, configPath, reportType, reportName string, slowFails bool) (float32, error) {
+	var reportErr error
+	report := prepareEnvironmentReport(configPath, results)
+
+	var total Counter
+	fmt.Println("")
+	fmt.Println("Environment summary:")
+	for _, env := range report.Environments {
+		if env.Error != "" {
+			fmt.Printf("%v: FAILED TO RUN: %v\n", env.Name, env.Error)
+			total.Fail++
+			continue
+		}
+		fmt.Printf("%v: PASSED %d, SLOW %d, FAILED %d, SKIPPED %d (%v)\n", env.Name, env.Total.Pass, env.Total.Slow, env.Total.Fail, env.Total.Skip, env.Version.String())
+		if env.QualityGate != nil {
+			fmt.Printf("%v: quality gate %v\n", env.Name, env.QualityGate.String())
+		}
+		total.Pass += env.Total.Pass
+		total.Fail += env.Total.Fail
+		total.Skip += env.Total.Skip
+		total.Slow += env.Total.Slow
+	}
+
+	if hasReportType(reportType, REPORT_HTML) {
+		if err := OutputEnvironmentReportHTML(fmt.Sprintf("%v.html", reportName), &report); err != nil {
+			logger.Errorf("Failed to write HTML report to %v.html: %s", reportName, err)
+			reportErr = fmt.Errorf("failed to write HTML report to %v.html: %s", reportName, err)
+		}
+	}
+	if hasReportType(reportType, REPORT_JSON) {
+		if err := OutputEnvironmentReportJSON(fmt.Sprintf("%v.json", reportName), &report); err != nil {
+			logger.Errorf("Failed to write JSON report to %v.json: %s", reportName, err)
+			if reportErr == nil {
+				reportErr = fmt.Errorf("failed to write JSON report to %v.json: %s", reportName, err)
+			}
+		}
+	}
+	if hasReportType(reportType, REPORT_JUNIT) {
+		if err := OutputEnvironmentReportJUnit(fmt.Sprintf("%v.xml", reportName), results); err != nil {
+			logger.Errorf("Failed to write JUnit report to %v.xml: %s", reportName, err)
+			if reportErr == nil {
+				reportErr = fmt.Errorf("failed to write JUnit report to %v.xml: %s", reportName, err)
+			}
+		}
+	}
+	if hasReportType(reportType, REPORT_MARKDOWN) {
+		if err := OutputEnvironmentReportMarkdown(fmt.Sprintf("%v.md", reportName), &report); err != nil {
+			logger.Errorf("Failed to write Markdown report to %v.md: %s", reportName, err)
+			if reportErr == nil {
+				reportErr = fmt.Errorf("failed to write Markdown report to %v.md: %s", reportName, err)
+			}
+		}
+	}
+
+	summary := ReportSummary{Total: total}
+	return summary.GetStatus(slowFails), reportErr
+}
+
+func OutputEnvironmentReportJSON(reportName string, reportData *EnvironmentReport) error {
+	data, err := json.Marshal(*reportData)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reportName, data, 0644)
+}
+
+func OutputEnvironmentReportHTML(reportName string, reportData *EnvironmentReport) error {
+	report, err := os.Create(reportName)
+	if err != nil {
+		return err
+	}
+	defer report.Close()
+
+	report.WriteString(fmt.Sprintf("<html><head><title>cx1e2e test of %d environments - %v</title></head><body>", len(reportData.Environments), reportData.Timestamp))
+	report.WriteString("<h2>Settings</h2>")
+	report.WriteString(fmt.Sprintf("Test set defined in configuration %v<br>", html.EscapeString(reportData.Config)))
+	report.WriteString(fmt.Sprintf("Execution timestamp: %v.<br>", reportData.Timestamp))
+	if reportData.E2ESuffix != "" {
+		report.WriteString(fmt.Sprintf("Default object name suffix %%E2E_RUN_SUFFIX%% environment variable is set to %v.<br>", html.EscapeString(reportData.E2ESuffix)))
+	}
+
+	report.WriteString("<h2>Summary</h2>")
+	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th>Environment</th><th>Target</th><th>Versions</th><th>Pass</th><th>Slow</th><th>Fail</th><th>Skip</th><th>Teardown</th><th>Quality gate</th></tr>\n")
+	for _, env := range reportData.Environments {
+		if env.Error != "" {
+			report.WriteString(fmt.Sprintf("<tr><td>%v</td><td>%v</td><td colspan=7><span style='color:red'>FAILED TO RUN: %v</span></td></tr>\n", html.EscapeString(env.Name), html.EscapeString(env.Target), html.EscapeString(env.Error)))
+			continue
+		}
+		report.WriteString(fmt.Sprintf("<tr><td>%v</td><td>%v</td><td>%v</td>", html.EscapeString(env.Name), html.EscapeString(env.Target), env.Version.String()))
+		writeCell(report, env.Total.Pass, true)
+		writeColorCell(report, env.Total.Slow, "darkorange")
+		writeCell(report, env.Total.Fail, false)
+		writeCell(report, env.Total.Skip, false)
+		report.WriteString(fmt.Sprintf("<td>PASS: %d, FAIL: %d, SKIP: %d</td>", env.Teardown.Pass, env.Teardown.Fail, env.Teardown.Skip))
+		switch {
+		case env.QualityGate == nil:
+			report.WriteString("<td>&nbsp;</td></tr>\n")
+		case env.QualityGate.Passed:
+			report.WriteString("<td><span style='color:green'>PASSED</span></td></tr>\n")
+		default:
+			report.WriteString(fmt.Sprintf("<td><span style='color:red'>%v</span></td></tr>\n", env.QualityGate.String()))
+		}
+	}
+	report.WriteString("</table><br>")
+
+	report.WriteString("<h2>Details</h2>")
+	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th>Test Set</th><th>Test</th>")
+	for _, env := range reportData.Environments {
+		report.WriteString(fmt.Sprintf("<th>%v<br><span style='font-weight:normal'>%v</span></th>", html.EscapeString(env.Name), env.Version.CxOne))
+	}
+	report.WriteString("</tr>\n")
+
+	for _, t := range reportData.Tests {
+		report.WriteString(fmt.Sprintf("<tr><td>%v</td><td>%v</td>", html.EscapeString(t.Name), html.EscapeString(t.Test)))
+		for _, env := range reportData.Environments {
+			result, ok := t.Results[env.Name]
+			if !ok {
+				report.WriteString("<td>&nbsp;</td>")
+				continue
+			}
+			color := "green"
+			switch result.ResultType {
+			case TST_FAIL:
+				color = "red"
+			case TST_SKIP:
+				color = "orange"
+			case TST_SLOW:
+				color = "darkorange"
+			}
+			report.WriteString(fmt.Sprintf("<td><span style='color:%v'>%v</span><br>%.2fs</td>", color, result.Result, result.Duration))
+		}
+		report.WriteString("</tr>\n")
+	}
+	report.WriteString("</table>\n")
+
+	_, err = report.WriteString("</body></html>")
+	if err != nil {
+		return err
+	}
+	return report.Sync()
+}
@@ -0,0 +1,188 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/sirupsen/logrus"
+)
+
+// an event emitted during a run: SetStarted, TestStarted, TestFinished or RunFinished
+type Event interface {
+	isEvent()
+}
+
+// emitted before the tests of a set are run, also when they are all skipped
+type SetStarted struct {
+	Set string
+}
+
+// emitted before a test operation is run, tests which are skipped are not started
+type TestStarted struct {
+	Set        string
+	CRUD       string
+	Module     string
+	TestObject string
+}
+
+// emitted for every test operation, including skipped ones
+type TestFinished struct {
+	Result TestResult
+}
+
+// emitted once the run and the automatic teardown have completed and the reports were written
+type RunFinished struct {
+	Report *Report
+	Status float32
+}
+
+func (SetStarted) isEvent()   {}
+func (TestStarted) isEvent()  {}
+func (TestFinished) isEvent() {}
+func (RunFinished) isEvent()  {}
+
+// receives the events of a run, listeners are called one at a time even when tests run in parallel
+type Listener func(event Event)
+
+type eventEmitter struct {
+	lock      sync.Mutex
+	listeners []Listener
+}
+
+func (e *eventEmitter) Emit(event Event) {
+	if e == nil || len(e.listeners) == 0 {
+		return
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for _, listener := range e.listeners {
+		listener(event)
+	}
+}
+
+// the outcome of a run
+type RunResult struct {
+	Results     []TestResult // in the order in which the test sets ran
+	Report      Report
+	Status      float32 // share of the tests which passed, 1 if all passed and 0 if none passed
+	Interrupted bool    // the run was canceled or timed out before all tests had run
+}
+
+// runs the tests of a loaded configuration using an authenticated client, and reports progress to the listeners
+// reports are written as configured by Config.ReportType, an empty ReportType only logs the summary
+func Execute(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Config *TestConfig, listeners ...Listener) (*RunResult, error) {
+	if Config == nil {
+		return nil, fmt.Errorf("no test configuration provided")
+	}
+	if cx1client == nil {
+		return nil, fmt.Errorf("no Cx1 client provided")
+	}
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+
+	stop := func() {}
+	if Config.HandleInterrupts {
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+	}
+
+	if Config.Concurrency > 1 {
+		logger.Infof("Running parallel test sets with up to %d concurrent tests", Config.Concurrency)
+	}
+
+	Config.startRun(listeners)
+	if Config.Resume != nil {
+		Config.state.checkpoint.Restore(logger, Config.Resume, Config.state)
+	}
+
+	result := &RunResult{}
+	if err := Config.runHooks(ctx, logger, HOOK_BEFORE_ALL, nil, hookContext{}); err != nil {
+		logger.Errorf("The tests will not run: %s", err)
+		for id := range Config.Tests {
+			Config.state.events.Emit(SetStarted{Set: Config.Tests[id].Name})
+			result.Results = append(result.Results, Config.state.finished(Config.Tests[id].FailTests(logger, err.Error()))...)
+		}
+	} else {
+		result.Results = Config.runSets(ctx, cx1client, logger)
+	}
+	result.Interrupted = ctx.Err() != nil
+	if result.Interrupted {
+		Config.state.abortReason = getStopReason(ctx)
+	}
+	stop() // restore the default handling so that a second interrupt ends the teardown
+
+	if !result.Interrupted {
+		Config.state.checkpoint.Remove(logger)
+	}
+
+	if Config.AutoTeardown {
+		Config.RunAutoTeardown(cx1client, logger)
+	}
+
+	hc := hookContext{Result: "PASS"}
+	for _, r := range result.Results {
+		if r.Result == TST_FAIL {
+			hc.Result = "FAIL"
+			break
+		}
+	}
+	_ = Config.runHooks(context.Background(), logger, HOOK_AFTER_ALL, nil, hc) // also after an interrupted run, like the teardown
+
+	report, err := GenerateReport(&result.Results, logger, Config)
+	result.Report = report
+	result.Status = report.Summary.GetStatus(Config.FailOnSlow)
+
+	Config.state.events.Emit(RunFinished{Report: &result.Report, Status: result.Status})
+	return result, err
+}
+
+// prepares the state of a new run of the configuration
+func (c *TestConfig) startRun(listeners []Listener) {
+	c.testPool = newWorkerPool(c.Concurrency)
+	c.state = newRunState()
+	c.state.events = &eventEmitter{listeners: listeners}
+	c.state.checkpoint = newCheckpointWriter(c)
+}
+
+// the context of the teardown of a set after the run was interrupted, which a second interrupt cancels
+func (c *TestConfig) teardownContext(logger *logrus.Logger) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if !c.HandleInterrupts {
+		return ctx, cancel
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			logger.Warnf("Interrupted again, the remaining teardown tests will be skipped")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// runs the test sets batch by batch, the sets of a batch at the same time
+func (c *TestConfig) runSets(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger) []TestResult {
+	var all_results []TestResult
+	setPool := newWorkerPool(c.Concurrency)
+	for _, batch := range c.getBatches() {
+		batch_results := make([][]TestResult, len(batch))
+		setPool.Run(len(batch), func(id int) {
+			batch_results[id] = c.Tests[batch[id]].RunTests(ctx, cx1client, logger, c)
+		})
+		for _, results := range batch_results {
+			all_results = append(all_results, results...)
+		}
+	}
+	return all_results
+}
@@ -0,0 +1,314 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// a test of the Fakes module, which runs without Cx1 so that whole runs can be tested
+type fakeTest struct {
+	types.CRUDTest `yaml:",inline"`
+	Name           string  `yaml:"Name"`
+	Sleep          float64 `yaml:"Sleep"` // seconds each operation takes
+	Fail           string  `yaml:"Fail"`  // operations which fail, eg: CR
+	Found          bool    `yaml:"-"`     // the object was created or read
+	Canceled       bool    `yaml:"-"`     // the operation was canceled when the run stopped
+}
+
+const fakeModule = "Fake"
+
+func init() {
+	RegisterModule(Module{Key: "Fakes", Name: fakeModule, Order: 1000, New: func() TestRunner { return &fakeTest{} }})
+}
+
+func (t *fakeTest) Validate(ctx context.Context, CRUD string) error {
+	if t.Name == "" {
+		return fmt.Errorf("fake name is missing")
+	}
+	if CRUD == types.OP_DELETE && !t.Found {
+		return fmt.Errorf("%w before deleting", types.ErrNotRead)
+	}
+	return nil
+}
+
+func (t *fakeTest) String() string {
+	return t.Name
+}
+
+func (t *fakeTest) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *types.EnabledEngines) error {
+	return nil
+}
+
+func (t *fakeTest) GetModule() string {
+	return fakeModule
+}
+
+func (t *fakeTest) run(ctx context.Context, CRUD string) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Duration(t.Sleep * float64(time.Second))):
+	}
+	if strings.Contains(t.Fail, CRUD[:1]) {
+		return fmt.Errorf("%v %v failed", CRUD, t.Name)
+	}
+	return nil
+}
+
+func (t *fakeTest) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines, Registry *types.CleanupRegistry) error {
+	if err := t.run(ctx, types.OP_CREATE); err != nil {
+		return err
+	}
+	t.Found = true
+	Registry.Add(t.createdObject())
+	return nil
+}
+
+func (t *fakeTest) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines) error {
+	if err := t.run(ctx, types.OP_READ); err != nil {
+		return err
+	}
+	t.Found = true
+	return nil
+}
+
+func (t *fakeTest) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines) error {
+	return t.run(ctx, types.OP_UPDATE)
+}
+
+func (t *fakeTest) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines, Registry *types.CleanupRegistry) error {
+	if err := t.run(ctx, types.OP_DELETE); err != nil {
+		return err
+	}
+	Registry.Remove(t.createdObject())
+	return nil
+}
+
+func (t *fakeTest) DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object types.CreatedObject) error {
+	return nil
+}
+
+func (t *fakeTest) CancelInFlight(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger) error {
+	t.Canceled = true
+	return nil
+}
+
+func (t *fakeTest) createdObject() types.CreatedObject {
+	return types.CreatedObject{Module: fakeModule, ID: t.Name, Name: t.Name}
+}
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+// writes the configuration to a file and loads it like the command-line does
+func loadTestConfig(t *testing.T, config string) *TestConfig {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	conf, err := LoadConfig(newTestLogger(), path, ConfigOptions{})
+	if err != nil {
+		t.Fatalf("failed to load the configuration: %s", err)
+	}
+	conf.ReportName = filepath.Join(filepath.Dir(path), "report")
+	return &conf
+}
+
+func TestExecuteEvents(t *testing.T) {
+	config := loadTestConfig(t, `Tests:
+  - Name: first
+    Fakes:
+      - Name: a
+        Test: CRD
+      - Name: b
+        Test: C
+        Fail: C
+  - Name: second
+    DependsOn: [ first ]
+    Fakes:
+      - Name: c
+        Test: C
+`)
+
+	events := []string{}
+	var finished *RunFinished
+	result, err := Execute(context.Background(), &Cx1ClientGo.Cx1Client{}, newTestLogger(), config, func(event Event) {
+		switch e := event.(type) {
+		case SetStarted:
+			events = append(events, "set "+e.Set)
+		case TestStarted:
+			events = append(events, fmt.Sprintf("start %v %v", e.CRUD, e.TestObject))
+		case TestFinished:
+			events = append(events, fmt.Sprintf("%v %v %v", resultName(e.Result.Result), e.Result.CRUD, e.Result.TestObject))
+		case RunFinished:
+			finished = &e
+			events = append(events, "finished")
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{
+		"set first",
+		"start Create a", "PASS Create a",
+		"start Create b", "FAIL Create b",
+		"start Read a", "PASS Read a",
+		"start Delete a", "PASS Delete a",
+		"set second",
+		"SKIP Create c", // tests which are skipped are not started
+		"finished",
+	}
+	if strings.Join(events, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected events:\n%v\ngot:\n%v", strings.Join(expected, "\n"), strings.Join(events, "\n"))
+	}
+
+	if len(result.Results) != 5 || result.Interrupted {
+		t.Errorf("expected 5 results of a run which was not interrupted, got %d, interrupted %v", len(result.Results), result.Interrupted)
+	}
+	if result.Status != 0.6 {
+		t.Errorf("expected a status of 0.6, got %v", result.Status)
+	}
+	if total := result.Report.Summary.Total; total.Pass != 3 || total.Fail != 1 || total.Skip != 1 {
+		t.Errorf("expected 3 passed, 1 failed and 1 skipped test in the report, got %+v", total)
+	}
+	if finished == nil || finished.Status != result.Status || finished.Report.Summary.Total != result.Report.Summary.Total {
+		t.Errorf("expected the RunFinished event to have the report and status of the result, got %v", finished)
+	}
+}
+
+func TestExecuteArguments(t *testing.T) {
+	tests := []struct {
+		name   string
+		client *Cx1ClientGo.Cx1Client
+		config *TestConfig
+		err    string
+	}{
+		{"no configuration", &Cx1ClientGo.Cx1Client{}, nil, "no test configuration provided"},
+		{"no client", nil, &TestConfig{}, "no Cx1 client provided"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := Execute(context.Background(), test.client, newTestLogger(), test.config)
+			if result != nil || err == nil || err.Error() != test.err {
+				t.Errorf("expected error %q without a result, got %v %v", test.err, result, err)
+			}
+		})
+	}
+}
+
+func TestExecuteAutoTeardown(t *testing.T) {
+	config := loadTestConfig(t, `Tests:
+  - Name: objects
+    Fakes:
+      - Name: deleted
+        Test: CD
+      - Name: kept
+        Test: C
+`)
+	config.AutoTeardown = true
+
+	result, err := Execute(context.Background(), &Cx1ClientGo.Cx1Client{}, newTestLogger(), config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(result.Report.AutoTeardown) != 1 || !strings.Contains(result.Report.AutoTeardown[0].Test, "kept") {
+		t.Errorf("expected only the object which was not deleted by a test to be deleted by the automatic teardown, got %v", result.Report.AutoTeardown)
+	}
+	if result.Status != 1 {
+		t.Errorf("expected the automatic teardown to not count towards the status, got %v", result.Status)
+	}
+}
+
+func TestExecuteInterrupted(t *testing.T) {
+	tests := []struct {
+		name   string
+		stop   func() (context.Context, context.CancelFunc)
+		cancel bool // CancelOnInterrupt
+		reason string
+	}{
+		{"interrupted", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+			return ctx, cancel
+		}, false, "interrupted"},
+		{"interrupted with CancelOnInterrupt", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+			return ctx, cancel
+		}, true, "interrupted"},
+		{"run timeout", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 100*time.Millisecond)
+		}, false, "timeout: run timeout exceeded"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := loadTestConfig(t, `Tests:
+  - Name: interrupted
+    Fakes:
+      - Name: running
+        Test: CD
+        Sleep: 5
+      - Name: later
+        Test: C
+    Teardown:
+      Fakes:
+        - Name: cleanup
+          Test: D
+  - Name: next
+    Fakes:
+      - Name: next
+        Test: C
+`)
+			config.CancelOnInterrupt = test.cancel
+
+			ctx, cancel := test.stop()
+			defer cancel()
+			result, err := Execute(ctx, &Cx1ClientGo.Cx1Client{}, newTestLogger(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			expected := []string{
+				"Create running FAIL " + test.reason + ": context ",
+				"Create later SKIP " + test.reason,
+				"Delete running SKIP " + test.reason,
+				"Delete cleanup PASS ", // the teardown still runs
+				"Create next SKIP " + test.reason,
+			}
+			if len(result.Results) != len(expected) {
+				t.Fatalf("expected %d results, got %v", len(expected), result.Results)
+			}
+			for id, r := range result.Results {
+				got := fmt.Sprintf("%v %v %v %v", r.CRUD, r.TestObject, resultName(r.Result), r.Reason)
+				if !strings.HasPrefix(got, expected[id]) {
+					t.Errorf("expected result %q, got %q", expected[id], got)
+				}
+			}
+
+			settings := result.Report.Settings
+			if !result.Interrupted || !settings.Aborted || settings.AbortReason != test.reason {
+				t.Errorf("expected an aborted run with reason %q, got interrupted %v, aborted %v with reason %q", test.reason, result.Interrupted, settings.Aborted, settings.AbortReason)
+			}
+			if canceled := config.Tests[0].Modules["Fakes"][0].(*fakeTest).Canceled; canceled != test.cancel {
+				t.Errorf("expected the running test to be canceled %v, got %v", test.cancel, canceled)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package process
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+)
+
+const (
+	SEL_SET    = "set"
+	SEL_MODULE = "module"
+	SEL_OP     = "op"
+	SEL_TAG    = "tag"
+)
+
+// a test matches a selector if it matches all of the selector's conditions
+type TestSelector struct {
+	Conditions []SelectorCondition
+}
+
+type SelectorCondition struct {
+	Kind    string
+	Pattern string
+	glob    bool
+	regex   *regexp.Regexp
+}
+
+// parses a comma-separated list of selectors such as "set:Access*,module:Scan+op:C,tag:/^smoke/"
+// conditions within a selector are joined with +, a condition without a kind matches the test set name
+func ParseSelectors(selectors string) ([]TestSelector, error) {
+	list := []TestSelector{}
+	if strings.TrimSpace(selectors) == "" {
+		return list, nil
+	}
+
+	for _, sel := range strings.Split(selectors, ",") {
+		var selector TestSelector
+		for _, cond := range strings.Split(sel, "+") {
+			condition, err := parseCondition(strings.TrimSpace(cond))
+			if err != nil {
+				return list, fmt.Errorf("invalid selector %v: %s", sel, err)
+			}
+			selector.Conditions = append(selector.Conditions, condition)
+		}
+		list = append(list, selector)
+	}
+	return list, nil
+}
+
+func parseCondition(cond string) (SelectorCondition, error) {
+	condition := SelectorCondition{Kind: SEL_SET, Pattern: cond}
+	if parts := strings.SplitN(cond, ":", 2); len(parts) == 2 {
+		switch strings.ToLower(parts[0]) {
+		case SEL_SET, SEL_MODULE, SEL_OP, SEL_TAG:
+			condition.Kind = strings.ToLower(parts[0])
+			condition.Pattern = parts[1]
+		}
+	}
+
+	if condition.Pattern == "" {
+		return condition, fmt.Errorf("empty pattern")
+	}
+
+	var err error
+	if len(condition.Pattern) > 1 && strings.HasPrefix(condition.Pattern, "/") && strings.HasSuffix(condition.Pattern, "/") {
+		condition.regex, err = regexp.Compile(condition.Pattern[1 : len(condition.Pattern)-1])
+	} else {
+		condition.glob = true
+		condition.regex, err = regexp.Compile(globToRegexp(condition.Pattern))
+	}
+	return condition, err
+}
+
+// glob patterns are case-insensitive and must match the whole value, * also matches / since set names are not paths
+func globToRegexp(glob string) string {
+	var regex strings.Builder
+	regex.WriteString("(?i)^")
+	inClass := false
+	for _, r := range glob {
+		switch {
+		case inClass:
+			if r == ']' {
+				inClass = false
+			}
+			regex.WriteRune(r)
+		case r == '*':
+			regex.WriteString(".*")
+		case r == '?':
+			regex.WriteString(".")
+		case r == '[':
+			inClass = true
+			regex.WriteRune(r)
+		default:
+			regex.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	regex.WriteString("$")
+	return regex.String()
+}
+
+func (c SelectorCondition) match(value string) bool {
+	return c.regex.MatchString(value)
+}
+
+func (c SelectorCondition) Matches(set *TestSet, CRUD string, test TestRunner) bool {
+	switch c.Kind {
+	case SEL_SET:
+		return c.match(set.Name)
+	case SEL_MODULE:
+		return c.match(test.GetModule())
+	case SEL_OP:
+		if c.glob && strings.Trim(strings.ToUpper(c.Pattern), "CRUD") == "" { // short form like "CR"
+			return strings.Contains(strings.ToUpper(c.Pattern), CRUD[:1])
+		}
+		return c.match(CRUD)
+	case SEL_TAG:
+		for _, tag := range append(append([]string{}, set.Tags...), test.GetTags()...) {
+			if c.match(tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s TestSelector) Matches(set *TestSet, CRUD string, test TestRunner) bool {
+	for _, c := range s.Conditions {
+		if !c.Matches(set, CRUD, test) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s TestSelector) String() string {
+	conditions := make([]string, len(s.Conditions))
+	for id, c := range s.Conditions {
+		conditions[id] = fmt.Sprintf("%v:%v", c.Kind, c.Pattern)
+	}
+	return strings.Join(conditions, "+")
+}
+
+func (c *TestConfig) IsFiltered() bool {
+	return len(c.Include) > 0 || len(c.Exclude) > 0
+}
+
+// is the test selected by the --include and --exclude selectors
+func (c *TestConfig) IsSelected(set *TestSet, CRUD string, test TestRunner) bool {
+	if len(c.Include) > 0 {
+		included := false
+		for _, s := range c.Include {
+			if s.Matches(set, CRUD, test) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, s := range c.Exclude {
+		if s.Matches(set, CRUD, test) {
+			return false
+		}
+	}
+	return true
+}
+
+// does the set contain any test that is selected to run
+func (c *TestConfig) HasSelectedTests(set *TestSet) bool {
+	for _, CRUD := range []string{types.OP_CREATE, types.OP_READ, types.OP_UPDATE, types.OP_DELETE} {
+		for _, tests := range set.GetModuleTests() {
+			for _, test := range tests {
+				if test.IsType(CRUD) && c.IsSelected(set, CRUD, test) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
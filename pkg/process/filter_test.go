@@ -0,0 +1,125 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob    string
+		value   string
+		matches bool
+	}{
+		{"Access*", "access management", true},
+		{"Access*", "user access", false},
+		{"*access*", "user access management", true},
+		{"scan ?", "Scan 1", true},
+		{"scan ?", "scan 10", false},
+		{"set [ab]", "set b", true},
+		{"set [ab]", "set c", false},
+		{"matrix [engine=*]", "matrix [engine=sast]", false}, // [ starts a character class
+		{"matrix *sast*", "matrix [engine=sast]", true},
+		{"a/b*", "a/b/c", true}, // * also matches / in set names
+		{"1.5", "105", false},   // regular expression characters are literal
+		{"(x)", "(x)", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.glob+" "+test.value, func(t *testing.T) {
+			conditions, err := ParseSelectors(test.glob)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if matches := conditions[0].Conditions[0].match(test.value); matches != test.matches {
+				t.Errorf("expected %v, got %v for regexp %v", test.matches, matches, globToRegexp(test.glob))
+			}
+		})
+	}
+}
+
+func TestParseSelectors(t *testing.T) {
+	tests := []struct {
+		selectors string
+		count     int
+		kinds     []string
+		err       bool
+	}{
+		{"", 0, nil, false},
+		{"smoke", 1, []string{SEL_SET}, false},
+		{"set:Access*,module:Scan+op:C", 2, []string{SEL_SET, SEL_MODULE, SEL_OP}, false},
+		{"TAG:/^smoke-.*/", 1, []string{SEL_TAG}, false},
+		{"other:value", 1, []string{SEL_SET}, false}, // unknown kinds are part of a set name
+		{"module:", 0, nil, true},
+		{"tag:/[/", 0, nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.selectors, func(t *testing.T) {
+			selectors, err := ParseSelectors(test.selectors)
+			if test.err {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(selectors) != test.count {
+				t.Fatalf("expected %d selectors, got %d", test.count, len(selectors))
+			}
+			kinds := []string{}
+			for _, s := range selectors {
+				for _, c := range s.Conditions {
+					kinds = append(kinds, c.Kind)
+				}
+			}
+			if len(kinds) != len(test.kinds) {
+				t.Fatalf("expected conditions %v, got %v", test.kinds, kinds)
+			}
+			for id := range kinds {
+				if kinds[id] != test.kinds[id] {
+					t.Errorf("expected conditions %v, got %v", test.kinds, kinds)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	set := &TestSet{Name: "access management", Tags: []string{"iam"}}
+	group := &types.GroupCRUD{}
+	group.TestTags = []string{"groups"}
+
+	tests := []struct {
+		selector string
+		CRUD     string
+		matches  bool
+	}{
+		{"access*", types.OP_CREATE, true},
+		{"module:Group", types.OP_CREATE, true},
+		{"module:User", types.OP_CREATE, false},
+		{"op:CR", types.OP_READ, true},
+		{"op:CR", types.OP_DELETE, false},
+		{"op:Delete", types.OP_DELETE, true},
+		{"tag:iam", types.OP_CREATE, true},
+		{"tag:group*", types.OP_CREATE, true},
+		{"tag:smoke", types.OP_CREATE, false},
+		{"tag:iam+op:D", types.OP_CREATE, false},
+		{"tag:/^gr/+module:group", types.OP_UPDATE, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.selector+" "+test.CRUD, func(t *testing.T) {
+			selectors, err := ParseSelectors(test.selector)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if matches := selectors[0].Matches(set, test.CRUD, group); matches != test.matches {
+				t.Errorf("expected %v, got %v", test.matches, matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package process
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strings"
+)
+
+// rules which the results of a run must meet for it to pass, instead of requiring every test to pass
+type QualityGate struct {
+	SkipsFail bool          `yaml:"SkipsFail"` // skipped tests count as failed, otherwise they are ignored
+	Rules     []QualityRule `yaml:"Rules"`
+}
+
+// a rule applies to the tests of any of its Modules which have any of its Tags, or to all tests if neither is set
+type QualityRule struct {
+	Name        string   `yaml:"Name"`        // shown in the reports, default: a description of the rule
+	Modules     []string `yaml:"Modules"`     // module names or report areas, eg: Scan, Access
+	Tags        []string `yaml:"Tags"`        // tags of the tests or their test sets
+	MaxFail     *int     `yaml:"MaxFail"`     // at most this many tests may fail, 0 for none
+	MinPassRate float64  `yaml:"MinPassRate"` // at least this percentage of the tests must pass
+}
+
+type QualityGateResult struct {
+	Passed bool
+	Rules  []QualityRuleResult
+}
+
+type QualityRuleResult struct {
+	Rule   string
+	Passed bool
+	Tests  Counter
+	Reason string `json:",omitempty"` // why the rule did not pass
+}
+
+func (c *TestConfig) validateQualityGate() error {
+	if c.QualityGate == nil {
+		return nil
+	}
+	if len(c.QualityGate.Rules) == 0 {
+		return fmt.Errorf("QualityGate has no Rules")
+	}
+
+	for _, rule := range c.QualityGate.Rules {
+		if rule.MaxFail == nil && rule.MinPassRate == 0 {
+			return fmt.Errorf("quality gate rule '%v' must have MaxFail or MinPassRate", rule.String())
+		}
+		if rule.MaxFail != nil && *rule.MaxFail < 0 {
+			return fmt.Errorf("quality gate rule '%v' has a negative MaxFail", rule.String())
+		}
+		if rule.MinPassRate < 0 || rule.MinPassRate > 100 {
+			return fmt.Errorf("quality gate rule '%v' has a MinPassRate outside of 0-100", rule.String())
+		}
+		for _, name := range rule.Modules {
+			if !isModuleOrArea(name) {
+				return fmt.Errorf("quality gate rule '%v' refers to unknown module '%v'", rule.String(), name)
+			}
+		}
+	}
+	return nil
+}
+
+func isModuleOrArea(name string) bool {
+	for _, module := range GetModules() {
+		if strings.EqualFold(module.Name, name) || strings.EqualFold(module.Area, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r QualityRule) String() string {
+	if r.Name != "" {
+		return r.Name
+	}
+
+	conditions := []string{}
+	if r.MaxFail != nil && *r.MaxFail == 0 {
+		conditions = append(conditions, "no failures")
+	} else if r.MaxFail != nil {
+		conditions = append(conditions, fmt.Sprintf("at most %d failures", *r.MaxFail))
+	}
+	if r.MinPassRate > 0 {
+		conditions = append(conditions, fmt.Sprintf("at least %g%% passed", r.MinPassRate))
+	}
+
+	scope := "all tests"
+	if len(r.Modules) > 0 {
+		scope = strings.Join(r.Modules, ", ")
+	}
+	if len(r.Tags) > 0 && len(r.Modules) > 0 {
+		scope = fmt.Sprintf("%v tests tagged %v", scope, strings.Join(r.Tags, ", "))
+	} else if len(r.Tags) > 0 {
+		scope = fmt.Sprintf("tests tagged %v", strings.Join(r.Tags, ", "))
+	}
+	return fmt.Sprintf("%v in %v", strings.Join(conditions, " and "), scope)
+}
+
+func (r QualityRule) appliesTo(result *TestResult, setTags []string) bool {
+	if len(r.Modules) > 0 {
+		module, _ := GetModuleByName(result.Module)
+		if !containsString(r.Modules, result.Module) && !containsString(r.Modules, module.Area) {
+			return false
+		}
+	}
+	if len(r.Tags) > 0 {
+		for _, tag := range append(append([]string{}, setTags...), result.Tags...) {
+			if containsString(r.Tags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// checks the results of the tests against the rules, the results of teardown blocks are not included as in the summary
+func (c *TestConfig) EvaluateQualityGate(results []TestResult) *QualityGateResult {
+	if c.QualityGate == nil {
+		return nil
+	}
+
+	setTags := make(map[string][]string)
+	for _, set := range c.Tests {
+		setTags[set.Name] = set.Tags
+	}
+
+	gate := &QualityGateResult{Passed: true}
+	for _, rule := range c.QualityGate.Rules {
+		ruleResult := QualityRuleResult{Rule: rule.String(), Passed: true}
+		for id := range results {
+			if results[id].Phase != PHASE_TEARDOWN && rule.appliesTo(&results[id], setTags[results[id].Name]) {
+				ruleResult.Tests.AddTest(&results[id])
+			}
+		}
+
+		failed, counted := ruleResult.Tests.Fail, ruleResult.Tests.Pass+ruleResult.Tests.Fail+ruleResult.Tests.Slow
+		if c.FailOnSlow {
+			failed += ruleResult.Tests.Slow
+		}
+		if c.QualityGate.SkipsFail {
+			failed += ruleResult.Tests.Skip
+			counted += ruleResult.Tests.Skip
+		}
+
+		reasons := []string{}
+		if rule.MaxFail != nil && failed > uint(*rule.MaxFail) {
+			reasons = append(reasons, fmt.Sprintf("%d tests failed, at most %d allowed", failed, *rule.MaxFail))
+		}
+		if rule.MinPassRate > 0 && counted == 0 {
+			reasons = append(reasons, fmt.Sprintf("no tests were counted, at least %g%% required", rule.MinPassRate))
+		} else if rule.MinPassRate > 0 {
+			if passRate := float64(counted-failed) / float64(counted) * 100; passRate < rule.MinPassRate {
+				reasons = append(reasons, fmt.Sprintf("%.1f%% passed, at least %g%% required", passRate, rule.MinPassRate))
+			}
+		}
+		if len(reasons) > 0 {
+			ruleResult.Passed = false
+			ruleResult.Reason = strings.Join(reasons, ", ")
+			gate.Passed = false
+		}
+		gate.Rules = append(gate.Rules, ruleResult)
+	}
+	return gate
+}
+
+// the results of load and soak iterations include the automatic teardown, which is not part of the gate of a normal run either
+func withoutAutoTeardown(results []TestResult) []TestResult {
+	filtered := make([]TestResult, 0, len(results))
+	for _, r := range results {
+		if !r.AutoTeardown {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+func (g *QualityGateResult) String() string {
+	if g.Passed {
+		return "PASSED"
+	}
+	failed := 0
+	for _, rule := range g.Rules {
+		if !rule.Passed {
+			failed++
+		}
+	}
+	return fmt.Sprintf("FAILED %d of %d rules", failed, len(g.Rules))
+}
+
+func (r QualityRuleResult) String() string {
+	if r.Passed {
+		return fmt.Sprintf("PASS %v", r.Rule)
+	}
+	return fmt.Sprintf("FAIL %v: %v", r.Rule, r.Reason)
+}
+
+func outputQualityGateConsole(gate *QualityGateResult) {
+	fmt.Println("")
+	fmt.Printf("Quality gate %v:\n", gate.String())
+	for _, rule := range gate.Rules {
+		fmt.Println(rule.String())
+	}
+}
+
+func writeQualityGate(report *os.File, gate *QualityGateResult) {
+	color := "green"
+	if !gate.Passed {
+		color = "red"
+	}
+	report.WriteString(fmt.Sprintf("<p>Quality gate: <span style='color:%v'>%v</span></p>", color, gate.String()))
+	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th>Rule</th><th>Pass</th><th>Slow</th><th>Fail</th><th>Skip</th><th>Result</th></tr>\n")
+	for _, rule := range gate.Rules {
+		report.WriteString(fmt.Sprintf("<tr><td>%v</td>", html.EscapeString(rule.Rule)))
+		writeCell(report, rule.Tests.Pass, true)
+		writeColorCell(report, rule.Tests.Slow, "darkorange")
+		writeCell(report, rule.Tests.Fail, false)
+		writeColorCell(report, rule.Tests.Skip, "orange")
+		if rule.Passed {
+			report.WriteString("<td><span style='color:green'>PASS</span></td></tr>\n")
+		} else {
+			report.WriteString(fmt.Sprintf("<td><span style='color:red'>FAIL: %v</span></td></tr>\n", html.EscapeString(rule.Reason)))
+		}
+	}
+	report.WriteString("</table><br>")
+}
@@ -0,0 +1,73 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+)
+
+func TestEvaluateQualityGate(t *testing.T) {
+	maxFail := func(count int) *int { return &count }
+	results := []TestResult{
+		{Result: TST_PASS, Module: types.MOD_GROUP, Name: "groups"},
+		{Result: TST_PASS, Module: types.MOD_GROUP, Name: "groups"},
+		{Result: TST_FAIL, Module: types.MOD_USER, Name: "users", Tags: []string{"smoke"}},
+		{Result: TST_SKIP, Module: types.MOD_USER, Name: "users"},
+		{Result: TST_FAIL, Module: types.MOD_GROUP, Name: "groups", Phase: PHASE_TEARDOWN},
+	}
+
+	tests := []struct {
+		name   string
+		gate   QualityGate
+		passed bool
+		reason string
+	}{
+		{"pass rate met", QualityGate{Rules: []QualityRule{{MinPassRate: 60}}}, true, ""},
+		{"pass rate missed", QualityGate{Rules: []QualityRule{{MinPassRate: 80}}}, false, "66.7% passed, at least 80% required"},
+		{"skips fail", QualityGate{SkipsFail: true, Rules: []QualityRule{{MinPassRate: 60}}}, false, "50.0% passed"},
+		{"max fail", QualityGate{Rules: []QualityRule{{MaxFail: maxFail(0), Modules: []string{types.MOD_GROUP}}}}, true, ""},
+		{"max fail exceeded", QualityGate{Rules: []QualityRule{{MaxFail: maxFail(0), Tags: []string{"smoke"}}}}, false, "1 tests failed, at most 0 allowed"},
+		{"no tests counted", QualityGate{Rules: []QualityRule{{MinPassRate: 90, Tags: []string{"missing"}}}}, false, "no tests were counted, at least 90% required"},
+		{"no tests counted without a pass rate", QualityGate{Rules: []QualityRule{{MaxFail: maxFail(0), Tags: []string{"missing"}}}}, true, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := TestConfig{QualityGate: &test.gate}
+			gate := config.EvaluateQualityGate(results)
+			if gate.Passed != test.passed || !strings.Contains(gate.Rules[0].Reason, test.reason) {
+				t.Errorf("expected passed %v with reason %q, got %v with %q", test.passed, test.reason, gate.Passed, gate.Rules[0].Reason)
+			}
+		})
+	}
+}
+
+func TestWithoutAutoTeardown(t *testing.T) {
+	// a test set may have the same name as the automatic teardown
+	results := []TestResult{{Name: "groups"}, {Name: autoTeardownName, AutoTeardown: true}, {Name: autoTeardownName}, {Name: "users"}}
+	if filtered := withoutAutoTeardown(results); len(filtered) != 3 || filtered[1].AutoTeardown || filtered[2].Name != "users" {
+		t.Errorf("expected only the automatic teardown to be removed, got %v", filtered)
+	}
+}
+
+func TestWriteQualityGate(t *testing.T) {
+	report, err := os.Create(filepath.Join(t.TempDir(), "report.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer report.Close()
+
+	writeQualityGate(report, &QualityGateResult{Rules: []QualityRuleResult{{Rule: "tag <smoke>", Reason: "no tests of <b>set</b> & tag were counted"}}})
+	data, err := os.ReadFile(report.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"<td>tag &lt;smoke&gt;</td>", "FAIL: no tests of &lt;b&gt;set&lt;/b&gt; &amp; tag were counted"} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("expected the report to contain %q, got %v", expected, string(data))
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	HOOK_BEFORE_ALL  = "BeforeAll"
+	HOOK_AFTER_ALL   = "AfterAll"
+	HOOK_BEFORE_SET  = "BeforeSet"
+	HOOK_AFTER_SET   = "AfterSet"
+	HOOK_BEFORE_TEST = "BeforeTest"
+	HOOK_AFTER_TEST  = "AfterTest"
+	HOOK_ON_FAILURE  = "OnFailure"
+)
+
+// hooks without a Timeout are stopped after this many seconds
+const defaultHookTimeout = 60
+
+// the last part of the output of a hook which is kept for the report
+const hookOutputLimit = 2000
+
+// an external command run before or after tests
+type Hook struct {
+	Command  string `yaml:"Command"`  // run with sh -c, or cmd /C on Windows
+	Timeout  int    `yaml:"Timeout"`  // seconds, default 60
+	FailTest bool   `yaml:"FailTest"` // a failure of the hook fails the test, or the tests of the set
+}
+
+// a hook can also be written as only its command
+func (h *Hook) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&h.Command); err == nil {
+		return nil
+	}
+	type hookFields Hook
+	return unmarshal((*hookFields)(h))
+}
+
+type Hooks struct {
+	BeforeAll  []Hook `yaml:"BeforeAll"`  // before the first test set, only in the main configuration
+	AfterAll   []Hook `yaml:"AfterAll"`   // after the last test set and the automatic teardown, only in the main configuration
+	BeforeSet  []Hook `yaml:"BeforeSet"`  // before the tests of each set
+	AfterSet   []Hook `yaml:"AfterSet"`   // after the tests of each set
+	BeforeTest []Hook `yaml:"BeforeTest"` // before each test operation
+	AfterTest  []Hook `yaml:"AfterTest"`  // after each test operation
+	OnFailure  []Hook `yaml:"OnFailure"`  // after each test operation which failed
+}
+
+// the outcome of a hook, included in the report
+type HookResult struct {
+	Hook     string
+	Command  string
+	Set      string `json:",omitempty"`
+	Test     string `json:",omitempty"` // eg: Create Group e2e-test-group
+	Duration float64
+	Result   string // PASS or FAIL
+	Reason   string `json:",omitempty"`
+	Output   string `json:",omitempty"`
+}
+
+func (h HookResult) String() string {
+	target := h.Set
+	if h.Test != "" {
+		target = fmt.Sprintf("%v - %v", h.Set, h.Test)
+	}
+	if target == "" {
+		return fmt.Sprintf("%v '%v': %v", h.Hook, h.Command, h.Reason)
+	}
+	return fmt.Sprintf("%v '%v' for %v: %v", h.Hook, h.Command, target, h.Reason)
+}
+
+// what the hook is run for, passed to the command as E2E_* environment variables
+type hookContext struct {
+	Env    string
+	Set    string
+	Phase  string
+	Module string
+	CRUD   string
+	Object string
+	Result string
+	Reason string
+}
+
+func (h *Hooks) get(kind string) []Hook {
+	if h == nil {
+		return nil
+	}
+	switch kind {
+	case HOOK_BEFORE_ALL:
+		return h.BeforeAll
+	case HOOK_AFTER_ALL:
+		return h.AfterAll
+	case HOOK_BEFORE_SET:
+		return h.BeforeSet
+	case HOOK_AFTER_SET:
+		return h.AfterSet
+	case HOOK_BEFORE_TEST:
+		return h.BeforeTest
+	case HOOK_AFTER_TEST:
+		return h.AfterTest
+	case HOOK_ON_FAILURE:
+		return h.OnFailure
+	}
+	return nil
+}
+
+func (h *Hooks) IsEmpty() bool {
+	for _, kind := range []string{HOOK_BEFORE_ALL, HOOK_AFTER_ALL, HOOK_BEFORE_SET, HOOK_AFTER_SET, HOOK_BEFORE_TEST, HOOK_AFTER_TEST, HOOK_ON_FAILURE} {
+		if len(h.get(kind)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *Hooks) Validate(setHooks bool) error {
+	for _, kind := range []string{HOOK_BEFORE_ALL, HOOK_AFTER_ALL, HOOK_BEFORE_SET, HOOK_AFTER_SET, HOOK_BEFORE_TEST, HOOK_AFTER_TEST, HOOK_ON_FAILURE} {
+		for _, hook := range h.get(kind) {
+			if setHooks && (kind == HOOK_BEFORE_ALL || kind == HOOK_AFTER_ALL) {
+				return fmt.Errorf("%v hooks can only be defined in the main configuration", kind)
+			}
+			if strings.TrimSpace(hook.Command) == "" {
+				return fmt.Errorf("%v hook has no Command", kind)
+			}
+			if hook.Timeout < 0 {
+				return fmt.Errorf("%v hook '%v' has a negative Timeout", kind, hook.Command)
+			}
+			if hook.FailTest && (kind == HOOK_AFTER_ALL || kind == HOOK_ON_FAILURE) {
+				return fmt.Errorf("%v hook '%v' can not use FailTest", kind, hook.Command)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *TestConfig) validateHooks() error {
+	if err := c.Hooks.Validate(false); err != nil {
+		return fmt.Errorf("config hooks: %s", err)
+	}
+	for id := range c.Tests {
+		if err := c.Tests[id].Hooks.Validate(true); err != nil {
+			return fmt.Errorf("test set '%v' hooks: %s", c.Tests[id].Name, err)
+		}
+	}
+	return nil
+}
+
+// runs the hooks of the configuration and then of the set, returns the failure of the first hook with FailTest
+func (c *TestConfig) runHooks(ctx context.Context, logger *logrus.Logger, kind string, set *TestSet, hc hookContext) error {
+	hooks := append([]Hook{}, c.Hooks.get(kind)...)
+	if set != nil {
+		hooks = append(hooks, set.Hooks.get(kind)...)
+	}
+
+	hc.Env = c.Environment
+	var failure error
+	for _, hook := range hooks {
+		result := hook.run(ctx, logger, kind, hc)
+		c.state.AddHookResult(result)
+		if result.Result == "FAIL" && hook.FailTest && failure == nil {
+			failure = fmt.Errorf("%v hook '%v' failed: %v", kind, hook.Command, result.Reason)
+		}
+	}
+	return failure
+}
+
+// runs the test between its BeforeTest and AfterTest hooks, followed by the OnFailure hooks if it failed
+func runWithHooks(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, phase, CRUD string, set *TestSet, test TestRunner, Config *TestConfig) TestResult {
+	var result TestResult
+	hc := hookContext{Set: set.Name, Phase: phase, Module: test.GetModule(), CRUD: CRUD, Object: test.String()}
+
+	if err := Config.runHooks(ctx, logger, HOOK_BEFORE_TEST, set, hc); err != nil {
+		result = MakeResult(test)
+		result.CRUD = CRUD
+		result.Name = set.Name
+		result.Result = TST_FAIL
+		result.Reason = err.Error()
+	} else {
+		result = Run(ctx, cx1client, logger, phase, CRUD, set.Name, test, Config.GetRetryPolicy(set, test), Config)
+		hc.Result, hc.Reason = resultName(result.Result), result.Reason
+		if err := Config.runHooks(ctx, logger, HOOK_AFTER_TEST, set, hc); err != nil && (result.Result == TST_PASS || result.Result == TST_SLOW) {
+			result.Result = TST_FAIL
+			result.Reason = err.Error()
+		}
+	}
+
+	if result.Result == TST_FAIL {
+		hc.Result, hc.Reason = resultName(result.Result), result.Reason
+		_ = Config.runHooks(ctx, logger, HOOK_ON_FAILURE, set, hc)
+	}
+	return result
+}
+
+func resultName(result int) string {
+	switch result {
+	case TST_PASS:
+		return "PASS"
+	case TST_FAIL:
+		return "FAIL"
+	case TST_SLOW:
+		return "SLOW"
+	}
+	return "SKIP"
+}
+
+// the hook is stopped when the run is interrupted or times out, or when its own Timeout expires
+func (h Hook) run(ctx context.Context, logger *logrus.Logger, kind string, hc hookContext) HookResult {
+	result := HookResult{
+		Hook:    kind,
+		Command: h.Command,
+		Set:     hc.Set,
+		Result:  "PASS",
+	}
+	if hc.CRUD != "" {
+		result.Test = fmt.Sprintf("%v %v %v", hc.CRUD, hc.Module, hc.Object)
+		if hc.Phase != "" {
+			result.Test = fmt.Sprintf("[%v] %v", hc.Phase, result.Test)
+		}
+	}
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = defaultHookTimeout
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(hookCtx, "cmd", "/C", h.Command)
+	} else {
+		cmd = exec.CommandContext(hookCtx, "sh", "-c", h.Command)
+	}
+	cmd.Env = append(os.Environ(),
+		"E2E_HOOK="+kind,
+		"E2E_ENVIRONMENT="+hc.Env,
+		"E2E_SET="+hc.Set,
+		"E2E_PHASE="+hc.Phase,
+		"E2E_MODULE="+hc.Module,
+		"E2E_CRUD="+hc.CRUD,
+		"E2E_OBJECT="+hc.Object,
+		"E2E_RESULT="+hc.Result,
+		"E2E_REASON="+hc.Reason,
+	)
+
+	// the output goes to a file rather than a pipe, so that the hook can not hang the run by leaving child processes behind
+	output, err := os.CreateTemp("", "cx1e2e-hook-*.log")
+	if err != nil {
+		result.Result = "FAIL"
+		result.Reason = fmt.Sprintf("failed to create output file: %s", err)
+		return result
+	}
+	defer os.Remove(output.Name())
+	defer output.Close()
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	logger.Debugf("Running %v hook: %v", kind, h.Command)
+	start := time.Now()
+	err = cmd.Run()
+	result.Duration = time.Since(start).Seconds()
+
+	if _, seekErr := output.Seek(0, io.SeekStart); seekErr == nil {
+		data, _ := io.ReadAll(output)
+		if len(data) > hookOutputLimit {
+			data = data[len(data)-hookOutputLimit:]
+		}
+		result.Output = strings.TrimSpace(string(data))
+	}
+
+	err = getHookError(ctx, hookCtx, err, timeout)
+	if err != nil {
+		result.Result = "FAIL"
+		result.Reason = err.Error()
+		logger.Warnf("%v hook '%v' failed: %s", kind, h.Command, err)
+		if result.Output != "" {
+			logger.Warnf("Hook output: %v", result.Output)
+		}
+	} else {
+		logger.Debugf("%v hook '%v' completed in %.3fs", kind, h.Command, result.Duration)
+	}
+	return result
+}
+
+// a hook which failed after the run stopped or its Timeout expired was most likely killed, a hook which exited without an error passed
+func getHookError(ctx, hookCtx context.Context, err error, timeout int) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("stopped: %v", getStopReason(ctx))
+	} else if hookCtx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %d seconds", timeout)
+	}
+	return err
+}
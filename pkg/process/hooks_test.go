@@ -0,0 +1,187 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"gopkg.in/yaml.v2"
+)
+
+func TestHookUnmarshal(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected []Hook
+	}{
+		{"shorthand", `BeforeTest: [ "echo before" ]`, []Hook{{Command: "echo before"}}},
+		{"fields", "BeforeTest:\n  - Command: echo before\n    Timeout: 5\n    FailTest: true", []Hook{{Command: "echo before", Timeout: 5, FailTest: true}}},
+		{"mixed", "BeforeTest:\n  - echo first\n  - Command: echo second\n    Timeout: 1", []Hook{{Command: "echo first"}, {Command: "echo second", Timeout: 1}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var hooks Hooks
+			if err := yaml.UnmarshalStrict([]byte(test.yaml), &hooks); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if fmt.Sprint(hooks.BeforeTest) != fmt.Sprint(test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, hooks.BeforeTest)
+			}
+		})
+	}
+}
+
+func TestHooksValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		hooks    Hooks
+		setHooks bool
+		err      string
+	}{
+		{"config BeforeAll", Hooks{BeforeAll: []Hook{{Command: "true"}}}, false, ""},
+		{"set BeforeAll", Hooks{BeforeAll: []Hook{{Command: "true"}}}, true, "BeforeAll hooks can only be defined in the main configuration"},
+		{"set AfterAll", Hooks{AfterAll: []Hook{{Command: "true"}}}, true, "AfterAll hooks can only be defined in the main configuration"},
+		{"set BeforeSet", Hooks{BeforeSet: []Hook{{Command: "true", FailTest: true}}}, true, ""},
+		{"no command", Hooks{AfterTest: []Hook{{Command: " "}}}, false, "AfterTest hook has no Command"},
+		{"negative timeout", Hooks{BeforeTest: []Hook{{Command: "true", Timeout: -1}}}, false, "BeforeTest hook 'true' has a negative Timeout"},
+		{"OnFailure FailTest", Hooks{OnFailure: []Hook{{Command: "true", FailTest: true}}}, false, "OnFailure hook 'true' can not use FailTest"},
+		{"AfterAll FailTest", Hooks{AfterAll: []Hook{{Command: "true", FailTest: true}}}, false, "AfterAll hook 'true' can not use FailTest"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.hooks.Validate(test.setHooks)
+			if test.err == "" && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			} else if test.err != "" && (err == nil || err.Error() != test.err) {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestRunWithHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks of this test are shell commands")
+	}
+
+	tests := []struct {
+		name     string
+		hooks    string
+		results  string   // the result of the tests pass and fail
+		hooksRun []string // the hooks which ran, with their result
+	}{
+		{
+			"AfterTest with FailTest fails a passed test",
+			"AfterTest:\n    - Command: exit 1\n      FailTest: true",
+			"FAIL FAIL",
+			[]string{"AfterTest Create Fake pass FAIL", "AfterTest Create Fake fail FAIL"},
+		},
+		{
+			"AfterTest without FailTest",
+			"AfterTest: [ exit 1 ]",
+			"PASS FAIL",
+			[]string{"AfterTest Create Fake pass FAIL", "AfterTest Create Fake fail FAIL"},
+		},
+		{
+			"BeforeTest with FailTest fails without running the test",
+			"BeforeTest:\n    - Command: exit 1\n      FailTest: true",
+			"FAIL FAIL",
+			[]string{"BeforeTest Create Fake pass FAIL", "BeforeTest Create Fake fail FAIL"},
+		},
+		{
+			"OnFailure only runs for failed tests",
+			"OnFailure: [ test \"$E2E_RESULT\" = FAIL ]",
+			"PASS FAIL",
+			[]string{"OnFailure Create Fake fail PASS"},
+		},
+		{
+			"Timeout",
+			"AfterTest:\n    - Command: sleep 5\n      Timeout: 1\n      FailTest: true",
+			"FAIL FAIL",
+			[]string{"AfterTest Create Fake pass FAIL timed out after 1 seconds", "AfterTest Create Fake fail FAIL timed out after 1 seconds"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := loadTestConfig(t, `Hooks:
+  `+test.hooks+`
+Tests:
+  - Name: hooks
+    Fakes:
+      - Name: pass
+        Test: C
+      - Name: fail
+        Test: C
+        Fail: C
+`)
+			result, err := Execute(context.Background(), &Cx1ClientGo.Cx1Client{}, newTestLogger(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			results := []string{}
+			for _, r := range result.Results {
+				results = append(results, resultName(r.Result))
+			}
+			if strings.Join(results, " ") != test.results {
+				t.Errorf("expected results %v, got %v", test.results, strings.Join(results, " "))
+			}
+
+			hooksRun := []string{}
+			for _, h := range config.state.GetHookResults() {
+				hook := fmt.Sprintf("%v %v %v", h.Hook, h.Test, h.Result)
+				if strings.HasPrefix(h.Reason, "timed out") {
+					hook += " " + h.Reason
+				}
+				hooksRun = append(hooksRun, hook)
+			}
+			if strings.Join(hooksRun, "\n") != strings.Join(test.hooksRun, "\n") {
+				t.Errorf("expected hooks:\n%v\ngot:\n%v", strings.Join(test.hooksRun, "\n"), strings.Join(hooksRun, "\n"))
+			}
+		})
+	}
+}
+
+func TestGetHookError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithTimeout(context.Background(), 0)
+	defer cancelExpired()
+	<-expired.Done()
+	running := context.Background()
+	exitError := fmt.Errorf("exit status 1")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		hookCtx context.Context
+		err     error
+		result  string
+	}{
+		{"passed", running, running, nil, ""},
+		{"failed", running, running, exitError, "exit status 1"},
+		{"killed when the run stopped", canceled, canceled, exitError, "stopped: interrupted"},
+		{"killed when the run timed out", expired, expired, exitError, "stopped: timeout: run timeout exceeded"},
+		{"killed by its Timeout", running, expired, exitError, "timed out after 5 seconds"},
+		{"passed before the run stopped", canceled, canceled, nil, ""},
+		{"passed before its Timeout", running, expired, nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := ""
+			if err := getHookError(test.ctx, test.hookCtx, test.err, 5); err != nil {
+				result = err.Error()
+			}
+			if result != test.result {
+				t.Errorf("expected %q, got %q", test.result, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package process
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	Timestamp  string           `xml:"timestamp,attr,omitempty"`
+	Properties *junitProperties `xml:"properties"`
+	Cases      []junitTestCase  `xml:"testcase"`
+
+	duration float64
+}
+
+type junitTestCase struct {
+	Name       string           `xml:"name,attr"`
+	ClassName  string           `xml:"classname,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *junitProperties `xml:"properties"`
+	Failure    *junitMessage    `xml:"failure"`
+	Skipped    *junitMessage    `xml:"skipped"`
+	SystemOut  string           `xml:"system-out,omitempty"`
+}
+
+// a pointer, so that tests without properties have no empty <properties> element
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// one testsuite per test set and one testcase per test, with the automatic teardown and the quality gate in suites of their own
+func prepareJUnitReport(reportData *Report) junitTestSuites {
+	report := junitTestSuites{Name: "cx1e2e"}
+
+	suites := make(map[string]int) // test set name -> index
+	addCase := func(suiteName string, details ReportTestDetails) {
+		id, ok := suites[suiteName]
+		if !ok {
+			id = len(report.Suites)
+			suites[suiteName] = id
+			report.Suites = append(report.Suites, junitTestSuite{Name: suiteName})
+		}
+		report.Suites[id].add(makeJUnitTestCase(suiteName, details))
+		report.Suites[id].duration += details.Duration
+	}
+
+	for _, details := range reportData.Details {
+		addCase(details.Name, details)
+	}
+	for _, details := range reportData.Teardown {
+		addCase(details.Name, details)
+	}
+	for _, details := range reportData.AutoTeardown {
+		addCase("Automatic teardown", details)
+	}
+
+	if reportData.QualityGate != nil {
+		suite := junitTestSuite{Name: "Quality gate"}
+		for _, rule := range reportData.QualityGate.Rules {
+			testCase := junitTestCase{Name: rule.Rule, ClassName: suite.Name, Time: "0.000"}
+			if !rule.Passed {
+				testCase.Failure = &junitMessage{Message: rule.Reason, Type: "FAIL", Text: rule.Reason}
+			}
+			suite.add(testCase)
+		}
+		report.Suites = append(report.Suites, suite)
+	}
+
+	var duration float64
+	for id := range report.Suites {
+		suite := &report.Suites[id]
+		suite.Time = fmt.Sprintf("%.3f", suite.duration)
+		if !reportData.Settings.started.IsZero() { // xs:dateTime without a time zone, as the JUnit schema expects
+			suite.Timestamp = reportData.Settings.started.Format("2006-01-02T15:04:05")
+		}
+		suite.Properties = &junitProperties{[]junitProperty{
+			{Name: "target", Value: reportData.Settings.Target},
+			{Name: "config", Value: reportData.Settings.Config},
+		}}
+		if reportData.Settings.Env != "" {
+			suite.Properties.Properties = append(suite.Properties.Properties, junitProperty{Name: "environment", Value: reportData.Settings.Env})
+		}
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		duration += suite.duration
+	}
+	report.Time = fmt.Sprintf("%.3f", duration)
+	return report
+}
+
+func (s *junitTestSuite) add(testCase junitTestCase) {
+	s.Tests++
+	if testCase.Failure != nil {
+		s.Failures++
+	}
+	if testCase.Skipped != nil {
+		s.Skipped++
+	}
+	s.Cases = append(s.Cases, testCase)
+}
+
+func makeJUnitTestCase(suiteName string, details ReportTestDetails) junitTestCase {
+	testCase := junitTestCase{
+		Name:      details.Test,
+		ClassName: suiteName,
+		Time:      fmt.Sprintf("%.3f", details.Duration),
+	}
+
+	for _, property := range []junitProperty{{"source", details.Source}, {"module", details.Module}, {"operation", details.CRUD}, {"phase", details.Phase}} {
+		if property.Value == "" {
+			continue
+		}
+		if testCase.Properties == nil {
+			testCase.Properties = &junitProperties{}
+		}
+		testCase.Properties.Properties = append(testCase.Properties.Properties, property)
+	}
+
+	switch details.ResultType {
+	case TST_FAIL:
+		testCase.Failure = &junitMessage{Message: details.Reason, Type: "FAIL", Text: details.Result}
+	case TST_SKIP:
+		testCase.Skipped = &junitMessage{Message: details.Reason}
+	case TST_SLOW: // passed, but the reason is kept in the output of the test
+		testCase.SystemOut = details.Result
+	}
+
+	if len(details.Attempts) > 1 {
+		attempts := []string{}
+		for id, a := range details.Attempts {
+			attempts = append(attempts, fmt.Sprintf("attempt %d [%.2fs] %v", id+1, a.Duration, a.Reason))
+		}
+		testCase.SystemOut = strings.TrimSpace(testCase.SystemOut + "\n" + strings.Join(attempts, "\n"))
+	}
+	return testCase
+}
+
+func OutputReportJUnit(reportName string, reportData *Report) error {
+	data, err := xml.MarshalIndent(prepareJUnitReport(reportData), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reportName, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
+
+// the suites of every environment, named after the environment and the test set, and a failed suite for each environment which did not run
+func OutputEnvironmentReportJUnit(reportName string, results []EnvironmentResult) error {
+	report := junitTestSuites{Name: "cx1e2e"}
+	for _, env := range results {
+		if env.Result == nil {
+			suite := junitTestSuite{Name: env.Environment.Name, Time: "0.000"}
+			suite.add(junitTestCase{Name: "run", ClassName: env.Environment.Name, Time: "0.000", Failure: &junitMessage{Message: env.Error, Type: "FAIL", Text: env.Error}})
+			report.Suites = append(report.Suites, suite)
+			report.Tests++
+			report.Failures++
+			continue
+		}
+
+		envReport := prepareJUnitReport(&env.Result.Report)
+		for _, suite := range envReport.Suites {
+			suite.Name = fmt.Sprintf("%v / %v", env.Environment.Name, suite.Name)
+			for id := range suite.Cases {
+				suite.Cases[id].ClassName = suite.Name
+			}
+			report.Suites = append(report.Suites, suite)
+		}
+		report.Tests += envReport.Tests
+		report.Failures += envReport.Failures
+		report.Skipped += envReport.Skipped
+	}
+
+	var duration float64
+	for _, suite := range report.Suites {
+		duration += suite.duration
+	}
+	report.Time = fmt.Sprintf("%.3f", duration)
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reportName, append([]byte(xml.Header), append(data, '\n')...), 0644)
+}
@@ -0,0 +1,104 @@
+package process
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+)
+
+func TestPrepareJUnitReport(t *testing.T) {
+	report := Report{
+		Settings: ReportSettings{Target: "https://cx1 tenant t", Config: "config.yaml", started: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)},
+		Details: []ReportTestDetails{
+			{Name: "groups", Source: "groups.yaml:3", Test: "Create Group g", Module: types.MOD_GROUP, CRUD: types.OP_CREATE, Duration: 1.5, ResultType: TST_PASS, Result: "PASS"},
+			{Name: "groups", Source: "groups.yaml:3", Test: "Read Group g", Module: types.MOD_GROUP, CRUD: types.OP_READ, Duration: 0.5, ResultType: TST_FAIL, Result: "FAIL: not found", Reason: "not found"},
+			{Name: "users", Test: "Create User u", Module: types.MOD_USER, CRUD: types.OP_CREATE, ResultType: TST_SKIP, Result: "SKIP: filtered", Reason: "filtered"},
+			{Name: "users", Test: "Read User u", Duration: 3, ResultType: TST_SLOW, Result: "SLOW: took 3.00s"},
+		},
+		Teardown: []ReportTestDetails{
+			{Name: "groups", Test: "Delete Group g", Phase: PHASE_TEARDOWN, Duration: 1, ResultType: TST_PASS, Result: "PASS"},
+		},
+		AutoTeardown: []ReportTestDetails{
+			{Name: autoTeardownName, Test: "Delete User u", ResultType: TST_FAIL, Result: "FAIL: denied", Reason: "denied"},
+		},
+		QualityGate: &QualityGateResult{Rules: []QualityRuleResult{{Rule: "no failures", Reason: "2 tests failed, at most 0 allowed"}, {Rule: "pass rate", Passed: true}}},
+	}
+	junit := prepareJUnitReport(&report)
+
+	if junit.Tests != 8 || junit.Failures != 3 || junit.Skipped != 1 || junit.Time != "6.000" {
+		t.Errorf("expected 8 tests, 3 failures, 1 skipped and 6 seconds, got %d, %d, %d and %v", junit.Tests, junit.Failures, junit.Skipped, junit.Time)
+	}
+
+	suites := []struct {
+		name     string
+		tests    int
+		failures int
+		skipped  int
+		time     string
+	}{
+		{"groups", 3, 1, 0, "3.000"}, // the teardown of the set is in the suite of the set
+		{"users", 2, 0, 1, "3.000"},
+		{"Automatic teardown", 1, 1, 0, "0.000"},
+		{"Quality gate", 2, 1, 0, "0.000"},
+	}
+	if len(junit.Suites) != len(suites) {
+		t.Fatalf("expected %d suites, got %d", len(suites), len(junit.Suites))
+	}
+	for id, expected := range suites {
+		t.Run(expected.name, func(t *testing.T) {
+			suite := junit.Suites[id]
+			if suite.Name != expected.name || suite.Tests != expected.tests || suite.Failures != expected.failures || suite.Skipped != expected.skipped || suite.Time != expected.time {
+				t.Errorf("expected %+v, got %v with %d tests, %d failures, %d skipped in %v", expected, suite.Name, suite.Tests, suite.Failures, suite.Skipped, suite.Time)
+			}
+			if suite.Timestamp != "2026-03-04T05:06:07" {
+				t.Errorf("expected timestamp 2026-03-04T05:06:07, got %v", suite.Timestamp)
+			}
+			if properties := fmt.Sprint(suite.Properties.Properties); properties != "[{target https://cx1 tenant t} {config config.yaml}]" {
+				t.Errorf("expected the target and config properties, got %v", properties)
+			}
+			for _, testCase := range suite.Cases {
+				if testCase.ClassName != expected.name {
+					t.Errorf("expected the class name of %v to be %v, got %v", testCase.Name, expected.name, testCase.ClassName)
+				}
+			}
+		})
+	}
+
+	cases := []struct {
+		suite      int
+		id         int
+		properties string
+		failure    string
+		skipped    string
+		output     string
+	}{
+		{0, 0, "[{source groups.yaml:3} {module Group} {operation Create}]", "", "", ""},
+		{0, 1, "[{source groups.yaml:3} {module Group} {operation Read}]", "not found", "", ""},
+		{0, 2, "[{phase Teardown}]", "", "", ""},
+		{1, 0, "[{module User} {operation Create}]", "", "filtered", ""},
+		{1, 1, "", "", "", "SLOW: took 3.00s"},
+		{2, 0, "", "denied", "", ""},
+		{3, 0, "", "2 tests failed, at most 0 allowed", "", ""},
+		{3, 1, "", "", "", ""},
+	}
+	for _, expected := range cases {
+		testCase := junit.Suites[expected.suite].Cases[expected.id]
+		t.Run(testCase.Name, func(t *testing.T) {
+			properties, failure, skipped := "", "", ""
+			if testCase.Properties != nil {
+				properties = fmt.Sprint(testCase.Properties.Properties)
+			}
+			if testCase.Failure != nil {
+				failure = testCase.Failure.Message
+			}
+			if testCase.Skipped != nil {
+				skipped = testCase.Skipped.Message
+			}
+			if properties != expected.properties || failure != expected.failure || skipped != expected.skipped || testCase.SystemOut != expected.output {
+				t.Errorf("expected properties %v, failure %q, skipped %q and output %q, got %v, %q, %q and %q", expected.properties, expected.failure, expected.skipped, expected.output, properties, failure, skipped, testCase.SystemOut)
+			}
+		})
+	}
+}
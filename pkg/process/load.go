@@ -0,0 +1,407 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// how the selected test sets are repeated in load-testing mode
+type LoadOptions struct {
+	Workers    int           // iterations running at the same time
+	Iterations int           // total number of iterations across all workers, 0 for no limit
+	Duration   time.Duration // no new iterations are started after this time, 0 for no limit
+}
+
+// the outcome of a load test
+type LoadReport struct {
+	Settings   ReportSettings `json:"Settings"`
+	Workers    int
+	Iterations int     // number of iterations which completed
+	Elapsed    float64 // seconds
+	Total      LoadStats
+	Operations []LoadStats // per module and CRUD operation
+
+	AutoTeardown *LoadStats `json:",omitempty"` // the objects deleted by the automatic teardown, which are not part of the other statistics
+
+	QualityGate *QualityGateResult `json:",omitempty"` // evaluated over the tests of all iterations
+}
+
+// statistics of one kind of operation, durations are in seconds and do not include skipped tests
+type LoadStats struct {
+	Module     string `json:",omitempty"`
+	CRUD       string `json:",omitempty"`
+	Count      uint
+	Counter    Counter
+	ErrorRate  float64 // share of the operations which failed
+	Throughput float64 // operations per second
+	Mean       float64
+	P50        float64
+	P90        float64
+	P99        float64
+	Max        float64
+	FirstError string `json:",omitempty"`
+
+	durations []float64
+}
+
+// load and soak tests have reports of their own, which can only be written as html and json
+func ValidateLoadReportType(reportType string) error {
+	for _, t := range []string{REPORT_JUNIT, REPORT_MARKDOWN} {
+		if hasReportType(reportType, t) {
+			return fmt.Errorf("report type '%v' is not supported by load and soak tests, use html or json", t)
+		}
+	}
+	return nil
+}
+
+func (o LoadOptions) Validate() error {
+	if o.Workers < 1 {
+		return fmt.Errorf("load test needs at least one worker")
+	}
+	if o.Iterations < 0 || o.Duration < 0 {
+		return fmt.Errorf("load test iterations and duration can not be negative")
+	}
+	return nil
+}
+
+// repeats the test sets of the configuration across the workers, every iteration loads the configuration again with its own object name suffix
+// prepare applies the settings which are not part of the configuration file, such as the selectors, to each loaded configuration
+func RunLoad(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Config *TestConfig, options ConfigOptions, load LoadOptions, prepare func(*TestConfig) error) (*LoadReport, error) {
+	if err := load.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ValidateLoadReportType(Config.ReportType); err != nil {
+		return nil, err
+	}
+	if Config.StepSummary {
+		logger.Warnf("StepSummary is not supported by load tests, the step summary will not be written")
+	}
+	if load.Iterations == 0 && load.Duration == 0 {
+		load.Iterations = load.Workers
+	}
+	// the suffix is the only thing which keeps the objects of the iterations apart
+	if !Config.usesRunSuffix {
+		if load.Workers > 1 {
+			return nil, fmt.Errorf("the configuration does not use %%E2E_RUN_SUFFIX%% in its object names, the iterations of %d workers would use the same objects", load.Workers)
+		}
+		logger.Warnf("The configuration does not use %%E2E_RUN_SUFFIX%% in its object names, every iteration will use the same objects")
+	}
+
+	Config.startRun(nil)
+	if err := Config.runHooks(ctx, logger, HOOK_BEFORE_ALL, nil, hookContext{}); err != nil {
+		return nil, fmt.Errorf("the load test will not run: %s", err)
+	}
+
+	var lock sync.Mutex
+	var results []TestResult
+	var loadErr error
+	next, completed := 0, 0
+
+	// returns the next iteration to run, or false once the iterations or the time have run out
+	claim := func(deadline time.Time) (int, bool) {
+		lock.Lock()
+		defer lock.Unlock()
+		if ctx.Err() != nil || loadErr != nil || (load.Iterations > 0 && next >= load.Iterations) || (load.Duration > 0 && time.Now().After(deadline)) {
+			return 0, false
+		}
+		next++
+		return next, true
+	}
+
+	logger.Infof("Starting load test with %d workers", load.Workers)
+	start := time.Now()
+	deadline := start.Add(load.Duration)
+	baseSuffix := os.Getenv("E2E_RUN_SUFFIX")
+
+	var wg sync.WaitGroup
+	for worker := 1; worker <= load.Workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for {
+				iteration, ok := claim(deadline)
+				if !ok {
+					return
+				}
+
+				suffix := fmt.Sprintf("%v-w%d-i%d", baseSuffix, worker, iteration)
+				iterationResults, err := runIteration(ctx, cx1client, logger, Config.ConfigPath, ConfigOptions{Lenient: options.Lenient, RunSuffix: suffix}, prepare)
+
+				lock.Lock()
+				if err != nil && loadErr == nil {
+					loadErr = fmt.Errorf("iteration %d failed to start: %s", iteration, err)
+				}
+				results = append(results, iterationResults...)
+				if err == nil {
+					completed++
+				}
+				lock.Unlock()
+			}
+		}(worker)
+	}
+	wg.Wait()
+	elapsed := time.Since(start).Seconds()
+	if ctx.Err() != nil {
+		Config.state.abortReason = getStopReason(ctx)
+	}
+
+	hc := hookContext{Result: "PASS"}
+	for _, r := range results {
+		if r.Result == TST_FAIL {
+			hc.Result = "FAIL"
+			break
+		}
+	}
+	_ = Config.runHooks(context.Background(), logger, HOOK_AFTER_ALL, nil, hc) // also after an interrupted run, like the teardown
+
+	report := prepareLoadReport(results, Config, load.Workers, completed, elapsed)
+	if err := GenerateLoadReport(&report, logger, Config); err != nil && loadErr == nil {
+		loadErr = err
+	}
+	return &report, loadErr
+}
+
+// loads the configuration again and runs it once, for load and soak tests
+func runIteration(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, configPath string, options ConfigOptions, prepare func(*TestConfig) error) ([]TestResult, error) {
+	iteration, err := LoadConfig(logger, configPath, options)
+	if err != nil {
+		return nil, err
+	}
+	if prepare != nil {
+		if err = prepare(&iteration); err != nil {
+			return nil, err
+		}
+	}
+	iteration.CheckpointPath = ""
+	iteration.Resume = nil
+	iteration.startRun(nil)
+
+	logger.Debugf("Starting iteration with suffix %v", options.RunSuffix)
+	results := iteration.runSets(ctx, cx1client, logger)
+	if iteration.AutoTeardown {
+		results = append(results, iteration.RunAutoTeardown(cx1client, logger)...)
+	}
+	return results, nil
+}
+
+func prepareLoadReport(results []TestResult, Config *TestConfig, workers, iterations int, elapsed float64) LoadReport {
+	report := LoadReport{
+		Workers:    workers,
+		Iterations: iterations,
+		Elapsed:    elapsed,
+	}
+	report.Settings = Config.getReportSettings()
+	report.QualityGate = Config.EvaluateQualityGate(withoutAutoTeardown(results))
+
+	operations := make(map[string]*LoadStats)
+	for id := range results {
+		r := &results[id]
+		if r.AutoTeardown {
+			if report.AutoTeardown == nil {
+				report.AutoTeardown = &LoadStats{Module: autoTeardownName}
+			}
+			report.AutoTeardown.add(r)
+			continue
+		}
+		key := r.Module + " " + r.CRUD
+		if operations[key] == nil {
+			operations[key] = &LoadStats{Module: r.Module, CRUD: r.CRUD}
+		}
+		operations[key].add(r)
+		report.Total.add(r)
+	}
+
+	for _, stats := range operations {
+		stats.calculate(elapsed)
+		report.Operations = append(report.Operations, *stats)
+	}
+	report.Total.calculate(elapsed)
+	if report.AutoTeardown != nil {
+		report.AutoTeardown.calculate(elapsed)
+	}
+
+	crudOrder := map[string]int{types.OP_CREATE: 0, types.OP_READ: 1, types.OP_UPDATE: 2, types.OP_DELETE: 3}
+	sort.Slice(report.Operations, func(i, j int) bool {
+		if report.Operations[i].Module != report.Operations[j].Module {
+			return report.Operations[i].Module < report.Operations[j].Module
+		}
+		return crudOrder[report.Operations[i].CRUD] < crudOrder[report.Operations[j].CRUD]
+	})
+	return report
+}
+
+func (s *LoadStats) add(r *TestResult) {
+	s.Counter.AddTest(r)
+	if r.Result == TST_SKIP {
+		return
+	}
+	s.Count++
+	s.durations = append(s.durations, r.Duration)
+	if r.Result == TST_FAIL && s.FirstError == "" {
+		s.FirstError = r.Reason
+	}
+}
+
+func (s *LoadStats) calculate(elapsed float64) {
+	if s.Count == 0 {
+		return
+	}
+	sort.Float64s(s.durations)
+	total := 0.0
+	for _, d := range s.durations {
+		total += d
+	}
+	s.Mean = total / float64(len(s.durations))
+	s.P50 = percentile(s.durations, 50)
+	s.P90 = percentile(s.durations, 90)
+	s.P99 = percentile(s.durations, 99)
+	s.Max = s.durations[len(s.durations)-1]
+	s.ErrorRate = float64(s.Counter.Fail) / float64(s.Count)
+	if elapsed > 0 {
+		s.Throughput = float64(s.Count) / elapsed
+	}
+}
+
+// nearest-rank percentile of sorted values
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// the share of the operations which passed, slow operations count as passed unless slowFails is set
+func (r *LoadReport) GetStatus(slowFails bool) float32 {
+	summary := ReportSummary{Total: r.Total.Counter}
+	return summary.GetStatus(slowFails)
+}
+
+func (s LoadStats) String() string {
+	return fmt.Sprintf("%-24v %6d ops %7.2f/s  errors %5.1f%%  p50 %.3fs  p90 %.3fs  p99 %.3fs  max %.3fs", s.name(), s.Count, s.Throughput, s.ErrorRate*100, s.P50, s.P90, s.P99, s.Max)
+}
+
+func (s LoadStats) name() string {
+	if s.Module == "" {
+		return "Total"
+	} else if s.CRUD == "" {
+		return s.Module
+	}
+	return fmt.Sprintf("%v %v", s.CRUD, s.Module)
+}
+
+// writes the load test report to the console and to the reports configured in Config.ReportType
+func GenerateLoadReport(report *LoadReport, logger *logrus.Logger, Config *TestConfig) error {
+	var reportErr error
+
+	fmt.Println("")
+	fmt.Printf("Load test: %d iterations with %d workers in %.1fs\n", report.Iterations, report.Workers, report.Elapsed)
+	for _, s := range report.Operations {
+		fmt.Println(s.String())
+		if s.FirstError != "" {
+			fmt.Printf("  first error: %v\n", s.FirstError)
+		}
+	}
+	fmt.Println(report.Total.String())
+	if report.AutoTeardown != nil {
+		fmt.Println(report.AutoTeardown.String())
+	}
+	if report.QualityGate != nil {
+		outputQualityGateConsole(report.QualityGate)
+	}
+	if report.Settings.Aborted {
+		fmt.Printf("ABORTED: %v, tests which did not run were skipped\n", report.Settings.AbortReason)
+	}
+	if throttling := report.Settings.throttling(); throttling != "" {
+		fmt.Printf("Throttled: %v\n", throttling)
+	}
+
+	if Config.HasReportType(REPORT_HTML) {
+		if err := OutputLoadReportHTML(fmt.Sprintf("%v.html", Config.ReportName), report); err != nil {
+			logger.Errorf("Failed to write HTML report to %v.html: %s", Config.ReportName, err)
+			reportErr = fmt.Errorf("failed to write HTML report to %v.html: %s", Config.ReportName, err)
+		}
+	}
+	if Config.HasReportType(REPORT_JSON) {
+		if err := OutputLoadReportJSON(fmt.Sprintf("%v.json", Config.ReportName), report); err != nil {
+			logger.Errorf("Failed to write JSON report to %v.json: %s", Config.ReportName, err)
+			if reportErr == nil {
+				reportErr = fmt.Errorf("failed to write JSON report to %v.json: %s", Config.ReportName, err)
+			}
+		}
+	}
+	return reportErr
+}
+
+func OutputLoadReportJSON(reportName string, reportData *LoadReport) error {
+	data, err := json.Marshal(*reportData)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reportName, data, 0644)
+}
+
+func OutputLoadReportHTML(reportName string, reportData *LoadReport) error {
+	report, err := os.Create(reportName)
+	if err != nil {
+		return err
+	}
+	defer report.Close()
+
+	report.WriteString(fmt.Sprintf("<html><head><title>%v load test - %v</title></head><body>", reportData.Settings.Target, reportData.Settings.Timestamp))
+	report.WriteString("<h2>Settings</h2>")
+	report.WriteString(fmt.Sprintf("Running load test against %v<br>", reportData.Settings.Target))
+	report.WriteString(fmt.Sprintf("Target versions are: %v<br>", reportData.Settings.Version.String()))
+	report.WriteString(fmt.Sprintf("Authenticated using %v<br>", reportData.Settings.Auth))
+	report.WriteString(fmt.Sprintf("Test set defined in configuration %v<br>", reportData.Settings.Config))
+	report.WriteString(fmt.Sprintf("Execution timestamp: %v.<br>", reportData.Settings.Timestamp))
+	if reportData.Settings.Aborted {
+		report.WriteString(fmt.Sprintf("<span style='color:red'>Aborted: %v, tests which did not run were skipped.</span><br>", reportData.Settings.AbortReason))
+	}
+	if throttling := reportData.Settings.throttling(); throttling != "" {
+		report.WriteString(fmt.Sprintf("Throttled: %v.<br>", throttling))
+	}
+	report.WriteString(fmt.Sprintf("%d iterations with %d workers in %.1f seconds.<br>", reportData.Iterations, reportData.Workers, reportData.Elapsed))
+
+	if reportData.QualityGate != nil {
+		report.WriteString("<h2>Quality gate</h2>")
+		writeQualityGate(report, reportData.QualityGate)
+	}
+
+	report.WriteString("<h2>Operations</h2>")
+	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th>Operation</th><th>Count</th><th>Pass</th><th>Slow</th><th>Fail</th><th>Skip</th><th>Error rate</th><th>Throughput (/sec)</th><th>Mean (sec)</th><th>p50 (sec)</th><th>p90 (sec)</th><th>p99 (sec)</th><th>Max (sec)</th><th>First error</th></tr>\n")
+	stats := append(append([]LoadStats{}, reportData.Operations...), reportData.Total)
+	if reportData.AutoTeardown != nil {
+		stats = append(stats, *reportData.AutoTeardown)
+	}
+	for _, s := range stats {
+		name := s.name()
+		if s.Module == "" {
+			name = "<b>Total</b>"
+		}
+		report.WriteString(fmt.Sprintf("<tr><td>%v</td><td>%d</td>", name, s.Count))
+		writeCell(report, s.Counter.Pass, true)
+		writeColorCell(report, s.Counter.Slow, "darkorange")
+		writeCell(report, s.Counter.Fail, false)
+		writeColorCell(report, s.Counter.Skip, "orange")
+		report.WriteString(fmt.Sprintf("<td>%.1f%%</td><td>%.2f</td><td>%.3f</td><td>%.3f</td><td>%.3f</td><td>%.3f</td><td>%.3f</td><td>%v</td></tr>\n", s.ErrorRate*100, s.Throughput, s.Mean, s.P50, s.P90, s.P99, s.Max, html.EscapeString(s.FirstError)))
+	}
+	report.WriteString("</table>\n")
+
+	_, err = report.WriteString("</body></html>")
+	if err != nil {
+		return err
+	}
+	return report.Sync()
+}
@@ -0,0 +1,144 @@
+package process
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+func TestPercentile(t *testing.T) {
+	durations := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	tests := []struct {
+		name   string
+		sorted []float64
+		p      float64
+		value  float64
+	}{
+		{"median", durations, 50, 5},
+		{"p90", durations, 90, 9},
+		{"p99 is the largest of ten", durations, 99, 10},
+		{"maximum", durations, 100, 10},
+		{"p0 is the smallest", durations, 0, 1},
+		{"rounds up to the next rank", durations, 51, 6},
+		{"single value", []float64{0.5}, 99, 0.5},
+		{"two values", []float64{1, 3}, 50, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if value := percentile(test.sorted, test.p); value != test.value {
+				t.Errorf("expected %v, got %v", test.value, value)
+			}
+		})
+	}
+}
+
+func TestLoadRunSuffix(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	for _, config := range []struct {
+		path string
+		uses bool
+	}{
+		{"../../examples/group/create.yaml", true},
+		{"../../examples/all.yaml", true}, // through its sub-tests
+		{"../../examples/simple_config.yaml", false},
+	} {
+		conf, err := LoadConfig(logger, config.path, ConfigOptions{})
+		if err != nil {
+			t.Fatalf("failed to load %v: %s", config.path, err)
+		}
+		if conf.usesRunSuffix != config.uses {
+			t.Errorf("%v: expected usesRunSuffix %v, got %v", config.path, config.uses, conf.usesRunSuffix)
+		}
+	}
+}
+
+func TestIterationSlow(t *testing.T) {
+	config := loadTestConfig(t, `MaxDuration:
+  Fake: { Create: 0.01 }
+Tests:
+  - Name: slow
+    Fakes:
+      - Name: slow
+        Test: CR
+        Sleep: 0.05
+`)
+
+	results, err := runIteration(context.Background(), &Cx1ClientGo.Cx1Client{}, newTestLogger(), config.ConfigPath, ConfigOptions{RunSuffix: "-i1"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(results) != 2 || results[0].Result != TST_SLOW || results[1].Result != TST_PASS {
+		t.Errorf("expected the create of the iteration to be slow and the read to pass, got %v", results)
+	}
+}
+
+func TestPrepareLoadReport(t *testing.T) {
+	results := []TestResult{
+		{Name: "set", Module: "Group", CRUD: types.OP_CREATE, Result: TST_PASS, Duration: 1},
+		{Name: "set", Module: "Group", CRUD: types.OP_CREATE, Result: TST_FAIL, Duration: 3, Reason: "first error"},
+		{Name: "set", Module: "Group", CRUD: types.OP_DELETE, Result: TST_PASS, Duration: 2},
+		{Name: autoTeardownName, Module: "Group", CRUD: types.OP_DELETE, Result: TST_SKIP}, // a test set with the name of the automatic teardown
+		{Name: autoTeardownName, Module: "Group", CRUD: types.OP_DELETE, Result: TST_FAIL, Duration: 10, Reason: "teardown error", AutoTeardown: true},
+	}
+	report := prepareLoadReport(results, &TestConfig{}, 1, 2, 10)
+
+	tests := []struct {
+		name     string
+		stats    LoadStats
+		count    uint
+		skip     uint
+		errors   float64
+		max      float64
+		firstErr string
+	}{
+		{"Create Group", report.Operations[0], 2, 0, 0.5, 3, "first error"},
+		{"Delete Group", report.Operations[1], 1, 1, 0, 2, ""},
+		{"Total", report.Total, 3, 1, 1.0 / 3, 3, "first error"},
+		{autoTeardownName, *report.AutoTeardown, 1, 0, 1, 10, "teardown error"},
+	}
+	if len(report.Operations) != 2 || report.AutoTeardown == nil {
+		t.Fatalf("expected 2 operations and the automatic teardown, got %v %v", report.Operations, report.AutoTeardown)
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := test.stats
+			if s.name() != test.name {
+				t.Errorf("expected %v, got %v", test.name, s.name())
+			}
+			if s.Count != test.count || s.Counter.Skip != test.skip || s.ErrorRate != test.errors || s.Max != test.max || s.FirstError != test.firstErr {
+				t.Errorf("expected count %d, skipped %d, error rate %v, max %v and first error %q, got %d, %d, %v, %v and %q",
+					test.count, test.skip, test.errors, test.max, test.firstErr, s.Count, s.Counter.Skip, s.ErrorRate, s.Max, s.FirstError)
+			}
+		})
+	}
+}
+
+func TestValidateLoadReportType(t *testing.T) {
+	tests := []struct {
+		reportType string
+		err        string
+	}{
+		{"", ""},
+		{"html,json", ""},
+		{"json, junit", "report type 'junit' is not supported by load and soak tests, use html or json"},
+		{"markdown", "report type 'markdown' is not supported by load and soak tests, use html or json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.reportType, func(t *testing.T) {
+			err := ValidateLoadReportType(test.reportType)
+			if test.err == "" && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			} else if test.err != "" && (err == nil || err.Error() != test.err) {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
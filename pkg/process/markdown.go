@@ -0,0 +1,153 @@
+package process
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// environments which run at the same time append to the same step summary
+var stepSummaryLock sync.Mutex
+
+// a summary of the report for pull requests and CI job summaries, with the results per area and operation and the failed tests
+func makeReportMarkdown(reportData *Report) string {
+	var md strings.Builder
+
+	title := "cx1e2e results"
+	if reportData.Settings.Env != "" {
+		title = fmt.Sprintf("cx1e2e results for %v", reportData.Settings.Env)
+	}
+	md.WriteString(fmt.Sprintf("## %v\n\n", title))
+	md.WriteString(fmt.Sprintf("Tests against %v (%v) defined in %v, started %v.\n\n", reportData.Settings.Target, reportData.Settings.Version.String(), markdownEscape(reportData.Settings.Config), reportData.Settings.Timestamp))
+	if reportData.Settings.Aborted {
+		md.WriteString(fmt.Sprintf("> **Aborted:** %v, tests which did not run were skipped.\n\n", markdownEscape(reportData.Settings.AbortReason)))
+	}
+	if throttling := reportData.Settings.throttling(); throttling != "" {
+		md.WriteString(fmt.Sprintf("Throttled: %v.\n\n", throttling))
+	}
+
+	total := reportData.Summary.Total
+	md.WriteString(fmt.Sprintf("**FAIL: %d, SKIP: %d, SLOW: %d, PASS: %d**\n\n", total.Fail, total.Skip, total.Slow, total.Pass))
+
+	md.WriteString("| Area | Create | Read | Update | Delete |\n")
+	md.WriteString("| --- | --- | --- | --- | --- |\n")
+	modules := GetModules()
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Title < modules[j].Title })
+	for _, module := range modules {
+		count := reportData.Summary.Area[module.Area]
+		if count == nil || count.Create.total()+count.Read.total()+count.Update.total()+count.Delete.total() == 0 {
+			continue
+		}
+		md.WriteString(fmt.Sprintf("| %v | %v | %v | %v | %v |\n", module.Title, markdownCounter(count.Create), markdownCounter(count.Read), markdownCounter(count.Update), markdownCounter(count.Delete)))
+	}
+	md.WriteString("\nEach operation shows pass / slow / fail / skip.\n\n")
+
+	if gate := reportData.QualityGate; gate != nil {
+		md.WriteString(fmt.Sprintf("**Quality gate: %v**\n\n", gate.String()))
+		for _, rule := range gate.Rules {
+			md.WriteString(fmt.Sprintf("- %v\n", markdownEscape(rule.String())))
+		}
+		md.WriteString("\n")
+	}
+
+	failed := []ReportTestDetails{}
+	for _, details := range [][]ReportTestDetails{reportData.Details, reportData.Teardown, reportData.AutoTeardown} {
+		for _, d := range details {
+			if d.ResultType == TST_FAIL {
+				failed = append(failed, d)
+			}
+		}
+	}
+	if len(failed) > 0 {
+		md.WriteString(fmt.Sprintf("<details><summary>Failed tests (%d)</summary>\n\n", len(failed)))
+		md.WriteString("| Test set | Test | Duration (sec) | Reason |\n")
+		md.WriteString("| --- | --- | --- | --- |\n")
+		for _, d := range failed {
+			reason := d.Reason
+			if reason == "" {
+				reason = d.Result
+			}
+			set := markdownEscape(d.Name)
+			if d.Source != "" {
+				set = fmt.Sprintf("%v<br>(%v)", set, markdownEscape(d.Source))
+			}
+			md.WriteString(fmt.Sprintf("| %v | %v | %.2f | %v |\n", set, markdownEscape(d.Test), d.Duration, markdownEscape(reason)))
+		}
+		md.WriteString("\n</details>\n\n")
+	}
+
+	return md.String()
+}
+
+func (c Counter) total() uint {
+	return c.Pass + c.Slow + c.Fail + c.Skip
+}
+
+// eg: 3 / 0 / **1** / 0, or - if the operation had no tests
+func markdownCounter(c Counter) string {
+	if c.total() == 0 {
+		return "-"
+	}
+	fail := fmt.Sprintf("%d", c.Fail)
+	if c.Fail > 0 {
+		fail = fmt.Sprintf("**%d**", c.Fail)
+	}
+	return fmt.Sprintf("%d / %d / %v / %d", c.Pass, c.Slow, fail, c.Skip)
+}
+
+// text in a table cell can not contain line breaks or pipes, and is not meant to be rendered as html
+func markdownEscape(text string) string {
+	text = html.EscapeString(strings.TrimSpace(text))
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.ReplaceAll(text, "\r\n", "<br>")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+func OutputReportMarkdown(reportName string, reportData *Report) error {
+	return os.WriteFile(reportName, []byte(makeReportMarkdown(reportData)), 0644)
+}
+
+// appends the markdown report to the file named by GITHUB_STEP_SUMMARY, which GitHub Actions shows on the summary page of the job
+func writeStepSummary(reportData *Report, logger *logrus.Logger) error {
+	summaryFile := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryFile == "" {
+		logger.Warnf("StepSummary is set but the GITHUB_STEP_SUMMARY environment variable is not, the step summary was not written")
+		return nil
+	}
+
+	stepSummaryLock.Lock()
+	defer stepSummaryLock.Unlock()
+	summary, err := os.OpenFile(summaryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer summary.Close()
+	_, err = summary.WriteString(makeReportMarkdown(reportData))
+	return err
+}
+
+// the results of each environment, the report of each environment has the details
+func OutputEnvironmentReportMarkdown(reportName string, reportData *EnvironmentReport) error {
+	var md strings.Builder
+	md.WriteString(fmt.Sprintf("## cx1e2e results for %d environments\n\n", len(reportData.Environments)))
+	md.WriteString(fmt.Sprintf("Tests defined in %v, started %v.\n\n", markdownEscape(reportData.Config), reportData.Timestamp))
+	md.WriteString("| Environment | Versions | Pass | Slow | Fail | Skip | Quality gate |\n")
+	md.WriteString("| --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, env := range reportData.Environments {
+		if env.Error != "" {
+			md.WriteString(fmt.Sprintf("| %v | **failed to run:** %v | | | | | |\n", markdownEscape(env.Name), markdownEscape(env.Error)))
+			continue
+		}
+		gate := "-"
+		if env.QualityGate != nil {
+			gate = env.QualityGate.String()
+		}
+		md.WriteString(fmt.Sprintf("| %v | %v | %d | %d | %d | %d | %v |\n", markdownEscape(env.Name), env.Version.String(), env.Total.Pass, env.Total.Slow, env.Total.Fail, env.Total.Skip, gate))
+	}
+	return os.WriteFile(reportName, []byte(md.String()), 0644)
+}
@@ -0,0 +1,145 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+)
+
+func TestMarkdownEscape(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"plain", "Create Group e2e-group", "Create Group e2e-group"},
+		{"pipe", "a | b", "a \\| b"},
+		{"newline", "first\nsecond", "first<br>second"},
+		{"windows newline", "first\r\nsecond", "first<br>second"},
+		{"trailing newline", "reason\n", "reason"},
+		{"html", "<b>bold</b> & co", "&lt;b&gt;bold&lt;/b&gt; &amp; co"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if escaped := markdownEscape(test.text); escaped != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, escaped)
+			}
+		})
+	}
+}
+
+func TestMakeReportMarkdown(t *testing.T) {
+	group, _ := GetModuleByName(types.MOD_GROUP)
+	fake, _ := GetModuleByName(fakeModule)
+	summary := func(areas map[string]*CounterSet) ReportSummary {
+		s := ReportSummary{Area: areas}
+		for _, count := range areas {
+			for _, c := range []Counter{count.Create, count.Read, count.Update, count.Delete} {
+				s.Total.Pass += c.Pass
+				s.Total.Slow += c.Slow
+				s.Total.Fail += c.Fail
+				s.Total.Skip += c.Skip
+			}
+		}
+		return s
+	}
+
+	tests := []struct {
+		name     string
+		report   Report
+		contains []string
+		excludes []string
+	}{
+		{
+			"all passed",
+			Report{
+				Summary: summary(map[string]*CounterSet{group.Area: {Create: Counter{Pass: 2}, Delete: Counter{Pass: 1, Slow: 1}}, fake.Area: {}}),
+				Details: []ReportTestDetails{{Name: "groups", Test: "Create Group g", ResultType: TST_PASS}},
+			},
+			[]string{
+				"## cx1e2e results\n",
+				"**FAIL: 0, SKIP: 0, SLOW: 1, PASS: 3**",
+				"| Area | Create | Read | Update | Delete |\n| --- | --- | --- | --- | --- |\n",
+				"| " + group.Title + " | 2 / 0 / 0 / 0 | - | - | 1 / 1 / 0 / 0 |\n",
+			},
+			[]string{"| " + fake.Title + " |", "<details>", "Aborted", "Quality gate"},
+		},
+		{
+			"failures",
+			Report{
+				Settings: ReportSettings{Env: "EU", Aborted: true, AbortReason: "interrupted"},
+				Summary:  summary(map[string]*CounterSet{group.Area: {Create: Counter{Pass: 1, Fail: 1}, Read: Counter{Skip: 1}}, fake.Area: {Update: Counter{Fail: 1}}}),
+				Details: []ReportTestDetails{
+					{Name: "groups", Source: "groups.yaml:3", Test: "Create Group a|b", Duration: 1.5, ResultType: TST_FAIL, Result: "FAIL", Reason: "first line\nsecond | line"},
+					{Name: "groups", Test: "Create Group c", ResultType: TST_PASS},
+				},
+				AutoTeardown: []ReportTestDetails{{Name: autoTeardownName, Test: "Update Fake f", ResultType: TST_FAIL, Result: "FAIL: denied"}},
+			},
+			[]string{
+				"## cx1e2e results for EU\n",
+				"> **Aborted:** interrupted, tests which did not run were skipped.",
+				"**FAIL: 2, SKIP: 1, SLOW: 0, PASS: 1**",
+				"| " + group.Title + " | 1 / 0 / **1** / 0 | 0 / 0 / 0 / 1 | - | - |\n",
+				"| " + fake.Title + " | - | - | 0 / 0 / **1** / 0 | - |\n",
+				"<details><summary>Failed tests (2)</summary>\n\n| Test set | Test | Duration (sec) | Reason |\n| --- | --- | --- | --- |\n",
+				"| groups<br>(groups.yaml:3) | Create Group a\\|b | 1.50 | first line<br>second \\| line |\n",
+				"| Automatic teardown | Update Fake f | 0.00 | FAIL: denied |\n",
+				"\n</details>\n",
+			},
+			[]string{"Create Group c"},
+		},
+		{
+			"quality gate",
+			Report{
+				Summary:     summary(map[string]*CounterSet{group.Area: {Create: Counter{Pass: 1}}}),
+				QualityGate: &QualityGateResult{Passed: true, Rules: []QualityRuleResult{{Rule: "pass rate | 90%", Passed: true}}},
+			},
+			[]string{"**Quality gate: PASSED**", "- PASS pass rate \\| 90%"},
+			[]string{"<details>"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			md := makeReportMarkdown(&test.report)
+			for _, expected := range test.contains {
+				if !strings.Contains(md, expected) {
+					t.Errorf("expected the markdown to contain %q, got:\n%v", expected, md)
+				}
+			}
+			for _, unexpected := range test.excludes {
+				if strings.Contains(md, unexpected) {
+					t.Errorf("expected the markdown to not contain %q, got:\n%v", unexpected, md)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteStepSummary(t *testing.T) {
+	report := Report{Summary: ReportSummary{Total: Counter{Pass: 1}}}
+	summaryFile := filepath.Join(t.TempDir(), "summary.md")
+
+	t.Setenv("GITHUB_STEP_SUMMARY", "")
+	if err := writeStepSummary(&report, newTestLogger()); err != nil {
+		t.Errorf("expected no error without GITHUB_STEP_SUMMARY, got %s", err)
+	}
+
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryFile)
+	for i := 0; i < 2; i++ {
+		if err := writeStepSummary(&report, newTestLogger()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	data, err := os.ReadFile(summaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md := makeReportMarkdown(&report); string(data) != md+md {
+		t.Errorf("expected the step summary to be appended to the file twice, got:\n%v", string(data))
+	}
+}
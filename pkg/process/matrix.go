@@ -0,0 +1,123 @@
+package process
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+)
+
+// returns one instance of the set for each combination of the matrix values, or the set itself if it has no matrix
+func (t *TestSet) ExpandMatrix() ([]TestSet, error) {
+	if len(t.Matrix) == 0 {
+		return []TestSet{*t}, nil
+	}
+	if t.File != "" {
+		return nil, fmt.Errorf("test set '%v' can not have both a Matrix and a File", t.Name)
+	}
+
+	keys := make([]string, 0, len(t.Matrix))
+	for key, values := range t.Matrix {
+		if !types.VariableName.MatchString(key) {
+			return nil, fmt.Errorf("test set '%v' has invalid Matrix key '%v'", t.Name, key)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("test set '%v' has no values for Matrix key '%v'", t.Name, key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	combinations := []map[string]string{{}}
+	for _, key := range keys {
+		next := make([]map[string]string, 0, len(combinations)*len(t.Matrix[key]))
+		for _, combination := range combinations {
+			for _, value := range t.Matrix[key] {
+				c := make(map[string]string, len(combination)+1)
+				for k, v := range combination {
+					c[k] = v
+				}
+				c[key] = value
+				next = append(next, c)
+			}
+		}
+		combinations = next
+	}
+
+	instances := make([]TestSet, 0, len(combinations))
+	for _, combination := range combinations {
+		instance := deepCopy(reflect.ValueOf(*t)).Interface().(TestSet)
+		types.ExpandVariables(&instance, combination)
+		for _, tests := range instance.GetAllModuleTests() {
+			for _, test := range tests {
+				types.ExpandVariables(test, combination)
+			}
+		}
+
+		values := make([]string, len(keys))
+		for id, key := range keys {
+			values[id] = fmt.Sprintf("%v=%v", key, combination[key])
+		}
+		instance.Name = fmt.Sprintf("%v [%v]", t.Name, strings.Join(values, ", "))
+		instance.BaseName = t.Name
+		instance.Matrix = nil
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// does the set have this name, either its own or the name of the matrix it was generated from
+func (t *TestSet) HasName(name string) bool {
+	return t.Name == name || (t.BaseName != "" && t.BaseName == name)
+}
+
+// copies the value including everything referenced through pointers, slices and maps
+func deepCopy(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return value
+		}
+		dup := reflect.New(value.Type().Elem())
+		dup.Elem().Set(deepCopy(value.Elem()))
+		return dup
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		dup := reflect.New(value.Type()).Elem()
+		dup.Set(deepCopy(value.Elem()))
+		return dup
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		dup := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for id := 0; id < value.Len(); id++ {
+			dup.Index(id).Set(deepCopy(value.Index(id)))
+		}
+		return dup
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		dup := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			dup.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return dup
+	case reflect.Struct:
+		dup := reflect.New(value.Type()).Elem()
+		dup.Set(value)
+		for id := 0; id < value.NumField(); id++ {
+			if dup.Field(id).CanSet() {
+				dup.Field(id).Set(deepCopy(value.Field(id)))
+			}
+		}
+		return dup
+	}
+	return value
+}
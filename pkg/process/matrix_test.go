@@ -0,0 +1,99 @@
+package process
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"gopkg.in/yaml.v2"
+)
+
+func TestExpandMatrix(t *testing.T) {
+	tests := []struct {
+		name  string
+		yaml  string
+		names []string
+		tests []string
+		err   string
+	}{
+		{
+			name:  "no matrix",
+			yaml:  "Name: plain\nGroups: [ { Name: g, Test: C } ]",
+			names: []string{"plain"},
+			tests: []string{"g"},
+		},
+		{
+			name:  "single key",
+			yaml:  "Name: m\nMatrix: { env: [ dev, prod ] }\nGroups: [ { Name: 'g-${env}', Test: C } ]",
+			names: []string{"m [env=dev]", "m [env=prod]"},
+			tests: []string{"g-dev", "g-prod"},
+		},
+		{
+			name:  "keys are combined in sorted order",
+			yaml:  "Name: m\nMatrix: { b: [ 1, 2 ], a: [ x ] }\nGroups: [ { Name: 'g-${a}${b}-${other}', Test: C } ]",
+			names: []string{"m [a=x, b=1]", "m [a=x, b=2]"},
+			tests: []string{"g-x1-${other}", "g-x2-${other}"},
+		},
+		{
+			name: "invalid key",
+			yaml: "Name: m\nMatrix: { 1key: [ a ] }",
+			err:  "invalid Matrix key '1key'",
+		},
+		{
+			name: "key without values",
+			yaml: "Name: m\nMatrix: { env: [] }",
+			err:  "has no values for Matrix key 'env'",
+		},
+		{
+			name: "matrix and file",
+			yaml: "Name: m\nFile: other.yaml\nMatrix: { env: [ dev ] }",
+			err:  "can not have both a Matrix and a File",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var set TestSet
+			if err := yaml.UnmarshalStrict([]byte(test.yaml), &set); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			instances, err := set.ExpandMatrix()
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			names, tests := []string{}, []string{}
+			for _, instance := range instances {
+				names = append(names, instance.Name)
+				tests = append(tests, instance.Modules["Groups"][0].(*types.GroupCRUD).Name)
+				if len(test.names) > 1 && (instance.BaseName != set.Name || instance.Matrix != nil) {
+					t.Errorf("expected instance %v to have base name %v and no matrix", instance.Name, set.Name)
+				}
+			}
+			if strings.Join(names, "|") != strings.Join(test.names, "|") {
+				t.Errorf("expected sets %v, got %v", test.names, names)
+			}
+			if strings.Join(tests, "|") != strings.Join(test.tests, "|") {
+				t.Errorf("expected tests %v, got %v", test.tests, tests)
+			}
+		})
+	}
+
+	// the instances do not share the tests of the matrix
+	var set TestSet
+	if err := yaml.UnmarshalStrict([]byte("Name: m\nMatrix: { env: [ dev ] }\nGroups: [ { Name: 'g-${env}', Test: C } ]"), &set); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := set.ExpandMatrix(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if name := set.Modules["Groups"][0].(*types.GroupCRUD).Name; name != "g-${env}" {
+		t.Errorf("expected the matrix to be unchanged, got %v", name)
+	}
+}
@@ -0,0 +1,222 @@
+package process
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// a type of object which can be tested, such as groups or scans
+type Module struct {
+	Key   string            // yaml key of the list of tests in a test set, eg: Groups
+	Name  string            // name of the module in results and reports, eg: types.MOD_GROUP
+	Area  string            // key in the report summary, defaults to Name
+	Title string            // label in the HTML report, defaults to Name
+	Order int               // modules are run in increasing order within each Create, Read, Update, Delete phase
+	New   func() TestRunner // returns a pointer to a new, empty test, eg: &types.GroupCRUD{}
+
+	// deletes an object created during the run which is left at the end of it, when AutoTeardown is enabled
+	// defaults to the DeleteObject function of the test type if it implements types.ObjectDeleter
+	Teardown func(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object types.CreatedObject) error
+}
+
+var moduleRegistry struct {
+	lock    sync.RWMutex
+	modules []Module
+}
+
+var moduleKey = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+
+// adds a module to the registry, must be called before a configuration is loaded, for example from an init function
+// panics if the module is invalid or a module with the same key or name is already registered
+func RegisterModule(module Module) {
+	if !moduleKey.MatchString(module.Key) {
+		panic(fmt.Sprintf("module key '%v' must start with an upper-case letter and contain only letters, digits and underscores", module.Key))
+	}
+	if module.Name == "" || module.New == nil {
+		panic(fmt.Sprintf("module %v must have a name and a New function", module.Key))
+	}
+	if testType := reflect.TypeOf(module.New()); testType.Kind() != reflect.Pointer || testType.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("module %v New function must return a pointer to a struct, not %v", module.Key, testType))
+	}
+	if module.Area == "" {
+		module.Area = module.Name
+	}
+	if module.Title == "" {
+		module.Title = module.Name
+	}
+	if _, ok := module.New().(types.ObjectDeleter); ok && module.Teardown == nil {
+		newTest := module.New
+		module.Teardown = func(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object types.CreatedObject) error {
+			return newTest().(types.ObjectDeleter).DeleteObject(cx1client, logger, object)
+		}
+	}
+
+	moduleRegistry.lock.Lock()
+	defer moduleRegistry.lock.Unlock()
+	for _, m := range moduleRegistry.modules {
+		if m.Key == module.Key || m.Name == module.Name {
+			panic(fmt.Sprintf("module %v (%v) is already registered", module.Key, module.Name))
+		}
+	}
+	moduleRegistry.modules = append(moduleRegistry.modules, module)
+	sort.SliceStable(moduleRegistry.modules, func(i, j int) bool {
+		return moduleRegistry.modules[i].Order < moduleRegistry.modules[j].Order
+	})
+}
+
+// returns the registered modules in the order in which they run
+func GetModules() []Module {
+	moduleRegistry.lock.RLock()
+	defer moduleRegistry.lock.RUnlock()
+	return append([]Module{}, moduleRegistry.modules...)
+}
+
+func GetModuleByName(name string) (Module, bool) {
+	for _, m := range GetModules() {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Module{}, false
+}
+
+// the struct type of the module's tests, eg: types.GroupCRUD
+func (m Module) testType() reflect.Type {
+	return reflect.TypeOf(m.New()).Elem()
+}
+
+// implemented by tests which can be tagged, tags under the key "Tags" are added to the test unless its object has Tags of its own
+type tagAdder interface {
+	AddTags(tags []string)
+}
+
+// the type into which the module's tests are decoded, which has a Tags field in addition to the test if the test does not use that key itself
+func (m Module) decodeType() reflect.Type {
+	testType := m.testType()
+	if hasYAMLKey(testType, "Tags") || !reflect.PointerTo(testType).Implements(reflect.TypeOf((*tagAdder)(nil)).Elem()) {
+		return testType
+	}
+	return reflect.StructOf([]reflect.StructField{
+		{Name: "Test", Type: testType, Tag: `yaml:",inline"`},
+		{Name: "Tags", Type: reflect.TypeOf([]string{}), Tag: `yaml:"Tags"`},
+	})
+}
+
+func hasYAMLKey(structType reflect.Type, key string) bool {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if options == "inline" && field.Type.Kind() == reflect.Struct && hasYAMLKey(field.Type, key) {
+			return true
+		}
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	RegisterModule(Module{Key: "Flags", Name: types.MOD_FLAG, Order: 10, New: func() TestRunner { return &types.FlagCRUD{} }})
+	RegisterModule(Module{Key: "Imports", Name: types.MOD_IMPORT, Order: 20, New: func() TestRunner { return &types.ImportCRUD{} }})
+	RegisterModule(Module{Key: "Groups", Name: types.MOD_GROUP, Order: 30, New: func() TestRunner { return &types.GroupCRUD{} }})
+	RegisterModule(Module{Key: "Applications", Name: types.MOD_APPLICATION, Order: 40, New: func() TestRunner { return &types.ApplicationCRUD{} }})
+	RegisterModule(Module{Key: "Projects", Name: types.MOD_PROJECT, Order: 50, New: func() TestRunner { return &types.ProjectCRUD{} }})
+	RegisterModule(Module{Key: "Roles", Name: types.MOD_ROLE, Order: 60, New: func() TestRunner { return &types.RoleCRUD{} }})
+	RegisterModule(Module{Key: "Users", Name: types.MOD_USER, Order: 70, New: func() TestRunner { return &types.UserCRUD{} }})
+	RegisterModule(Module{Key: "AccessAssignments", Name: types.MOD_ACCESS, Area: "Access", Title: "Access Assignment", Order: 80, New: func() TestRunner { return &types.AccessAssignmentCRUD{} }})
+	RegisterModule(Module{Key: "Queries", Name: types.MOD_QUERY, Order: 90, New: func() TestRunner { return &types.CxQLCRUD{} }})
+	RegisterModule(Module{Key: "Presets", Name: types.MOD_PRESET, Order: 100, New: func() TestRunner { return &types.PresetCRUD{} }})
+	RegisterModule(Module{Key: "Scans", Name: types.MOD_SCAN, Order: 110, New: func() TestRunner { return &types.ScanCRUD{} }})
+	RegisterModule(Module{Key: "Results", Name: types.MOD_RESULT, Order: 120, New: func() TestRunner { return &types.ResultCRUD{} }})
+	RegisterModule(Module{Key: "Reports", Name: types.MOD_REPORT, Order: 130, New: func() TestRunner { return &types.ReportCRUD{} }})
+}
+
+type testSetFields TestSet // TestSet without its UnmarshalYAML method
+
+// decodes the common fields of the set, and the tests under the key of each registered module
+func (t *TestSet) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var fields testSetFields
+	modules, err := decodeModules(unmarshal, &fields, reflect.TypeOf(*t).String())
+	if err != nil {
+		return err
+	}
+	*t = TestSet(fields)
+	t.Modules = modules
+	return nil
+}
+
+// decodes the tests under the key of each registered module, and the other fields into common if it is not nil
+func decodeModules(unmarshal func(interface{}) error, common interface{}, typeName string) (map[string][]TestRunner, error) {
+	modules := GetModules()
+	fields := []reflect.StructField{}
+	if common != nil {
+		fields = append(fields, reflect.StructField{Name: "Common", Type: reflect.TypeOf(common).Elem(), Tag: `yaml:",inline"`})
+	}
+	offset := len(fields)
+	for _, m := range modules {
+		fields = append(fields, reflect.StructField{
+			Name: m.Key,
+			Type: reflect.SliceOf(m.decodeType()),
+			Tag:  reflect.StructTag(fmt.Sprintf(`yaml:"%v"`, m.Key)),
+		})
+	}
+
+	value := reflect.New(reflect.StructOf(fields)).Elem()
+	if err := unmarshal(value.Addr().Interface()); err != nil {
+		// report unknown keys against the named type rather than the generated struct
+		if typeErr, ok := err.(*yaml.TypeError); ok {
+			for id := range typeErr.Errors {
+				typeErr.Errors[id] = strings.ReplaceAll(typeErr.Errors[id], value.Type().String(), typeName)
+				for _, m := range modules {
+					typeErr.Errors[id] = strings.ReplaceAll(typeErr.Errors[id], m.decodeType().String(), m.testType().String())
+				}
+			}
+		}
+		return nil, err
+	}
+
+	if common != nil {
+		reflect.ValueOf(common).Elem().Set(value.Field(0))
+	}
+	tests := make(map[string][]TestRunner)
+	for id, m := range modules {
+		list := value.Field(offset + id)
+		for i := 0; i < list.Len(); i++ {
+			test := list.Index(i)
+			if test.Type() != m.testType() {
+				tags := test.FieldByName("Tags").Interface().([]string)
+				test = test.FieldByName("Test")
+				if len(tags) > 0 {
+					test.Addr().Interface().(tagAdder).AddTags(tags)
+				}
+			}
+			tests[m.Key] = append(tests[m.Key], test.Addr().Interface().(TestRunner))
+		}
+	}
+	return tests, nil
+}
+
+// the tests grouped per module, in the order in which the modules are executed
+func getModuleTests(modules map[string][]TestRunner) [][]TestRunner {
+	registered := GetModules()
+	tests := make([][]TestRunner, len(registered))
+	for id, m := range registered {
+		tests[id] = modules[m.Key]
+	}
+	return tests
+}
+
+// the tests in this set grouped per module, in the order in which the modules are executed
+func (t *TestSet) GetModuleTests() [][]TestRunner {
+	return getModuleTests(t.Modules)
+}
@@ -0,0 +1,63 @@
+package process
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"gopkg.in/yaml.v2"
+)
+
+func TestDecodeTestTags(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		key  string
+		tags []string
+	}{
+		{"Tags on a test", "Groups: [ { Name: g, Test: C, Tags: [ a, b ] } ]", "Groups", []string{"a", "b"}},
+		{"Tags and TestTags", "Groups: [ { Name: g, Test: C, TestTags: [ a ], Tags: [ b ] } ]", "Groups", []string{"a", "b"}},
+		{"object with its own Tags", "Projects: [ { Name: p, Test: C, Tags: [ { Key: k, Value: v } ], TestTags: [ a ] } ]", "Projects", []string{"a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var set TestSet
+			if err := yaml.UnmarshalStrict([]byte(test.yaml), &set); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			tags := set.Modules[test.key][0].GetTags()
+			if strings.Join(tags, ",") != strings.Join(test.tags, ",") {
+				t.Errorf("expected tags %v, got %v", test.tags, tags)
+			}
+		})
+	}
+
+	var set TestSet
+	if err := yaml.UnmarshalStrict([]byte("Projects: [ { Name: p, Tags: [ { Key: k, Value: v } ] } ]"), &set); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if project := set.Modules["Projects"][0].(*types.ProjectCRUD); len(project.Tags) != 1 || project.Tags[0].Key != "k" {
+		t.Errorf("expected the project tags to be decoded, got %v", project.Tags)
+	}
+}
+
+func TestModuleTeardown(t *testing.T) {
+	for _, module := range GetModules() {
+		_, deleter := module.New().(types.ObjectDeleter)
+		if deleter != (module.Teardown != nil) {
+			t.Errorf("module %v: expected a teardown %v, got %v", module.Name, deleter, module.Teardown != nil)
+		}
+	}
+
+	registry := &types.CleanupRegistry{}
+	object := types.CreatedObject{Module: types.MOD_GROUP, ID: "1"}
+	registry.Add(object)
+	if err := registry.Teardown(object, func(types.CreatedObject) error { return fmt.Errorf("failed") }); err == nil || len(registry.GetObjects()) != 1 {
+		t.Errorf("expected an object which failed to be deleted to be kept, got %v", registry.GetObjects())
+	}
+	if err := registry.Teardown(object, func(types.CreatedObject) error { return nil }); err != nil || len(registry.GetObjects()) != 0 {
+		t.Errorf("expected a deleted object to be removed, got %v", registry.GetObjects())
+	}
+}
@@ -0,0 +1,53 @@
+package process
+
+import "reflect"
+
+const (
+	PHASE_SETUP    = "Setup"
+	PHASE_TEST     = "" // the tests of the set itself
+	PHASE_TEARDOWN = "Teardown"
+)
+
+// the phases of a test set in the order in which they run
+var testPhases = []string{PHASE_SETUP, PHASE_TEST, PHASE_TEARDOWN}
+
+// the Setup or Teardown block of a test set, which contains the same module lists as the set itself
+type TestSection struct {
+	Modules map[string][]TestRunner `yaml:"-"`
+}
+
+func (s *TestSection) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	modules, err := decodeModules(unmarshal, nil, reflect.TypeOf(*s).String())
+	if err != nil {
+		return err
+	}
+	s.Modules = modules
+	return nil
+}
+
+func (s *TestSection) GetModuleTests() [][]TestRunner {
+	if s == nil {
+		return getModuleTests(nil)
+	}
+	return getModuleTests(s.Modules)
+}
+
+// the tests of the set which run in this phase, grouped per module
+func (t *TestSet) GetPhaseTests(phase string) [][]TestRunner {
+	switch phase {
+	case PHASE_SETUP:
+		return t.Setup.GetModuleTests()
+	case PHASE_TEARDOWN:
+		return t.Teardown.GetModuleTests()
+	}
+	return t.GetModuleTests()
+}
+
+// the tests of all phases grouped per module, for checks which apply to every test of the set
+func (t *TestSet) GetAllModuleTests() [][]TestRunner {
+	tests := [][]TestRunner{}
+	for _, phase := range testPhases {
+		tests = append(tests, t.GetPhaseTests(phase)...)
+	}
+	return tests
+}
@@ -0,0 +1,138 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+)
+
+func TestGetPhaseTests(t *testing.T) {
+	config := loadTestConfig(t, `Tests:
+  - Name: phases
+    Setup:
+      Fakes:
+        - Name: setup
+          Test: C
+    Fakes:
+      - Name: test1
+        Test: C
+      - Name: test2
+        Test: R
+    Teardown:
+      Fakes:
+        - Name: teardown
+          Test: D
+  - Name: tests only
+    Fakes:
+      - Name: test
+        Test: C
+`)
+
+	tests := []struct {
+		set      int
+		phase    string
+		expected string
+	}{
+		{0, PHASE_SETUP, "[[setup]]"},
+		{0, PHASE_TEST, "[[test1 test2]]"},
+		{0, PHASE_TEARDOWN, "[[teardown]]"},
+		{1, PHASE_SETUP, "[]"},
+		{1, PHASE_TEST, "[[test]]"},
+		{1, PHASE_TEARDOWN, "[]"},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%v %v", config.Tests[test.set].Name, test.phase), func(t *testing.T) {
+			if tests := fmt.Sprint(nonEmpty(config.Tests[test.set].GetPhaseTests(test.phase))); tests != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, tests)
+			}
+		})
+	}
+
+	if all := fmt.Sprint(nonEmpty(config.Tests[0].GetAllModuleTests())); all != "[[setup] [test1 test2] [teardown]]" {
+		t.Errorf("expected the tests of all phases in order, got %v", all)
+	}
+}
+
+// the tests are grouped per registered module, most of which have no tests here
+func nonEmpty(tests [][]TestRunner) [][]TestRunner {
+	modules := [][]TestRunner{}
+	for _, module := range tests {
+		if len(module) > 0 {
+			modules = append(modules, module)
+		}
+	}
+	return modules
+}
+
+func TestRunPhases(t *testing.T) {
+	tests := []struct {
+		name     string
+		set      string
+		expected []string // phase, operation, test, result and the start of the reason
+	}{
+		{
+			"all phases pass",
+			`    Setup:
+      Fakes: [ { Name: setup, Test: C } ]
+    Fakes: [ { Name: test, Test: CR } ]
+    Teardown:
+      Fakes: [ { Name: setup, Test: D } ]`,
+			[]string{"Setup Create setup PASS", " Create test PASS", " Read test PASS", "Teardown Delete setup PASS"},
+		},
+		{
+			"setup failure skips the tests but not the teardown",
+			`    Setup:
+      Fakes: [ { Name: setup, Test: C, Fail: C } ]
+    Fakes: [ { Name: test, Test: CR } ]
+    Teardown:
+      Fakes: [ { Name: teardown, Test: D } ]`,
+			[]string{
+				"Setup Create setup FAIL Create setup failed",
+				" Create test SKIP setup failed: Create Fake test (setup) failed",
+				" Read test SKIP setup failed: Create Fake test (setup) failed",
+				"Teardown Delete teardown PASS",
+			},
+		},
+		{
+			"teardown reads the object to delete",
+			`    Teardown:
+      Fakes: [ { Name: teardown, Test: D } ]`,
+			[]string{"Teardown Delete teardown PASS"},
+		},
+		{
+			"teardown skips objects which are not found",
+			`    Teardown:
+      Fakes: [ { Name: teardown, Test: D, Fail: R } ]`,
+			[]string{"Teardown Delete teardown SKIP object to delete was not found: Read teardown failed"},
+		},
+		{
+			"deleting an object which was not read outside of the teardown",
+			`    Fakes: [ { Name: test, Test: D } ]`,
+			[]string{" Delete test SKIP must read before deleting"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := loadTestConfig(t, "Tests:\n  - Name: phases\n"+test.set+"\n")
+			result, err := Execute(context.Background(), &Cx1ClientGo.Cx1Client{}, newTestLogger(), config)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if len(result.Results) != len(test.expected) {
+				t.Fatalf("expected %d results, got %d: %v", len(test.expected), len(result.Results), result.Results)
+			}
+			for id, r := range result.Results {
+				got := fmt.Sprintf("%v %v %v %v %v", r.Phase, r.CRUD, r.TestObject, resultName(r.Result), r.Reason)
+				if !strings.HasPrefix(got, test.expected[id]) {
+					t.Errorf("expected result %q, got %q", test.expected[id], got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+)
+
+// a single test operation as it would be executed by RunTests
+type PlanStep struct {
+	SetID      int
+	Set        string
+	Phase      string
+	CRUD       string
+	Module     string
+	TestObject string
+	Source     string
+	Filtered   bool
+	Warning    string
+	Error      string
+}
+
+// returns the tests in the order in which they would run, checking everything which can be validated without a Cx1 connection
+func (c *TestConfig) GetPlan() []PlanStep {
+	plan := []PlanStep{}
+	for setID := range c.Tests {
+		set := &c.Tests[setID]
+		for _, phase := range testPhases {
+			for _, CRUD := range []string{types.OP_CREATE, types.OP_READ, types.OP_UPDATE, types.OP_DELETE} {
+				for _, tests := range set.GetPhaseTests(phase) {
+					for _, test := range tests {
+						if !test.IsType(CRUD) {
+							continue
+						}
+						step := PlanStep{
+							SetID:      setID,
+							Set:        set.Name,
+							Phase:      phase,
+							CRUD:       CRUD,
+							Module:     test.GetModule(),
+							TestObject: test.String(),
+							Source:     test.GetSource(),
+						}
+						if phase == PHASE_TEST {
+							step.Filtered = !c.IsSelected(set, CRUD, test)
+						} else {
+							step.Filtered = c.IsFiltered() && !c.HasSelectedTests(set)
+						}
+
+						// objects which are read by an earlier test are not available yet, the teardown looks them up itself
+						// and a test which also creates the object has it once the create has run
+						if err := test.Validate(context.Background(), CRUD); err != nil {
+							if !errors.Is(err, types.ErrNotRead) {
+								step.Error = err.Error()
+							} else if !test.IsType(types.OP_READ) && !test.IsType(types.OP_CREATE) && !test.IsNegative() && !(phase == PHASE_TEARDOWN && CRUD == types.OP_DELETE) {
+								step.Warning = fmt.Sprintf("%s, but the test does not include a [R]ead", err)
+							}
+						}
+						plan = append(plan, step)
+					}
+				}
+			}
+		}
+	}
+	return plan
+}
+
+// prints the plan and returns the number of tests with configuration errors
+func (c *TestConfig) WritePlan(w io.Writer, plan []PlanStep) int {
+	errorCount, filteredCount := 0, 0
+	for _, step := range plan {
+		if step.Error != "" {
+			errorCount++
+		}
+		if step.Filtered {
+			filteredCount++
+		}
+	}
+
+	fmt.Fprintf(w, "Execution plan for %v: %d test sets, %d tests (%d filtered), %d errors\n", c.ConfigPath, len(c.Tests), len(plan), filteredCount, errorCount)
+
+	stepID := 0
+	for batchID, batch := range c.getBatches() {
+		for _, setID := range batch {
+			set := &c.Tests[setID]
+			details := []string{}
+			if len(batch) > 1 {
+				details = append(details, fmt.Sprintf("parallel batch %d", batchID+1))
+			}
+			if len(set.DependsOn) > 0 {
+				details = append(details, fmt.Sprintf("depends on %v", strings.Join(set.DependsOn, ", ")))
+			}
+			if set.Wait > 0 {
+				details = append(details, fmt.Sprintf("wait %ds", set.Wait))
+			}
+			if len(details) > 0 {
+				fmt.Fprintf(w, "\nTest set %d: %v (%v)\n", setID+1, set.Name, strings.Join(details, ", "))
+			} else {
+				fmt.Fprintf(w, "\nTest set %d: %v\n", setID+1, set.Name)
+			}
+
+			phased := hasTests(set.GetPhaseTests(PHASE_SETUP)) || hasTests(set.GetPhaseTests(PHASE_TEARDOWN))
+			lastPhase, indent := "-", ""
+			if phased {
+				indent = "  "
+			}
+			for ; stepID < len(plan) && plan[stepID].SetID == setID; stepID++ {
+				step := plan[stepID]
+				if phased && step.Phase != lastPhase {
+					lastPhase = step.Phase
+					if step.Phase == PHASE_TEST {
+						fmt.Fprintf(w, "  Tests:\n")
+					} else {
+						fmt.Fprintf(w, "  %v:\n", step.Phase)
+					}
+				}
+				status := ""
+				if step.Filtered {
+					status = " [filtered]"
+				}
+				fmt.Fprintf(w, "%v  %-6v %-16v %v%v\n", indent, step.CRUD, step.Module, step.TestObject, status)
+				if step.Error != "" {
+					fmt.Fprintf(w, "%v         ERROR: %v (%v)\n", indent, step.Error, step.Source)
+				}
+				if step.Warning != "" {
+					fmt.Fprintf(w, "%v         WARNING: %v (%v)\n", indent, step.Warning, step.Source)
+				}
+			}
+		}
+	}
+
+	return errorCount
+}
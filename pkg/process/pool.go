@@ -0,0 +1,41 @@
+package process
+
+import "sync"
+
+// workerPool bounds the number of concurrently executing jobs, a nil pool or a pool of size 1 runs everything in order
+type workerPool struct {
+	slots chan struct{}
+}
+
+func newWorkerPool(size int) *workerPool {
+	if size <= 1 {
+		return nil
+	}
+	return &workerPool{
+		slots: make(chan struct{}, size),
+	}
+}
+
+// Run calls fn for each id in [0,count) and returns once all calls have completed
+func (p *workerPool) Run(count int, fn func(id int)) {
+	if p == nil || count <= 1 {
+		for id := 0; id < count; id++ {
+			fn(id)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(count)
+	for id := 0; id < count; id++ {
+		p.slots <- struct{}{}
+		go func(id int) {
+			defer func() {
+				<-p.slots
+				wg.Done()
+			}()
+			fn(id)
+		}(id)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,68 @@
+package process
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWorkerPool(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		count      int
+		concurrent int // most jobs expected to run at the same time
+	}{
+		{"nil pool runs in order", 1, 5, 1},
+		{"pool of two", 2, 6, 2},
+		{"pool larger than the jobs", 8, 3, 3},
+		{"no jobs", 4, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pool := newWorkerPool(test.size)
+			if (pool == nil) != (test.size <= 1) {
+				t.Fatalf("expected a nil pool only for a size of 1 or less, got %v", pool)
+			}
+
+			var lock sync.Mutex
+			running, most := 0, 0
+			ran := make([]int, test.count)
+			order := []int{}
+			pool.Run(test.count, func(id int) {
+				lock.Lock()
+				running++
+				if running > most {
+					most = running
+				}
+				ran[id]++
+				order = append(order, id)
+				lock.Unlock()
+
+				time.Sleep(20 * time.Millisecond)
+
+				lock.Lock()
+				running--
+				lock.Unlock()
+			})
+
+			for id, count := range ran {
+				if count != 1 {
+					t.Errorf("expected job %d to run once, ran %d times", id, count)
+				}
+			}
+			if most != test.concurrent {
+				t.Errorf("expected at most %d jobs at the same time, got %d", test.concurrent, most)
+			}
+			if pool == nil {
+				for id := range order {
+					if order[id] != id {
+						t.Errorf("expected the jobs to run in order, got %v", order)
+						break
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// retries of a request which was rejected with 429 Too Many Requests, unless set in RateLimits
+const defaultRateLimitRetries = 3
+
+// the longest a rejected request waits before it is sent again, also when Retry-After asks for longer
+const maxRetryAfter = 5 * time.Minute
+
+// the highest rate at which requests are sent to a host, 0 for no limit
+type HostRateLimit struct {
+	RequestsPerSecond float64 `yaml:"RequestsPerSecond"`
+	Burst             int     `yaml:"Burst"` // requests which can be sent at once after an idle period, default 1
+}
+
+type RateLimits struct {
+	Cx1        HostRateLimit `yaml:"Cx1"`
+	IAM        HostRateLimit `yaml:"IAM"`
+	MaxRetries *int          `yaml:"MaxRetries"` // retries of a request which was rejected with 429 Too Many Requests, default 3, 0 to not retry
+}
+
+func (c *TestConfig) validateRateLimits() error {
+	for name, limit := range map[string]HostRateLimit{"Cx1": c.RateLimit.Cx1, "IAM": c.RateLimit.IAM} {
+		if limit.RequestsPerSecond < 0 || limit.Burst < 0 {
+			return fmt.Errorf("RateLimit for %v can not be negative", name)
+		}
+	}
+	if c.RateLimit.MaxRetries != nil && *c.RateLimit.MaxRetries < 0 {
+		return fmt.Errorf("RateLimit MaxRetries can not be negative")
+	}
+	return nil
+}
+
+type tokenBucket struct {
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+	lock   sync.Mutex
+}
+
+func newTokenBucket(limit HostRateLimit) *tokenBucket {
+	burst := math.Max(1, float64(limit.Burst))
+	return &tokenBucket{rate: limit.RequestsPerSecond, burst: burst, tokens: burst, last: time.Now()}
+}
+
+// takes a token and returns how long to wait until it may be used, tokens taken by waiting requests are not returned
+func (b *tokenBucket) reserve() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// an http.RoundTripper which limits the rate of requests to the Cx1 and IAM hosts,
+// and sends requests which were rejected with 429 Too Many Requests again after the Retry-After delay
+type RateLimiter struct {
+	next       http.RoundTripper
+	buckets    map[string]*tokenBucket // host -> limit, hosts without a limit are not throttled
+	maxRetries int
+	logger     *logrus.Logger
+
+	lock      sync.Mutex
+	throttled time.Duration // summed over all requests, so concurrent requests can be throttled for longer than the run took
+	rejected  int
+}
+
+// wraps the next transport, http.DefaultTransport if nil
+// if the Cx1 and IAM URLs have the same host, the Cx1 limit applies to both
+func NewRateLimiter(next http.RoundTripper, limits RateLimits, cx1URL, iamURL string, logger *logrus.Logger) (*RateLimiter, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	r := &RateLimiter{
+		next:       next,
+		buckets:    make(map[string]*tokenBucket),
+		maxRetries: defaultRateLimitRetries,
+		logger:     logger,
+	}
+	if limits.MaxRetries != nil {
+		r.maxRetries = *limits.MaxRetries
+	}
+
+	for _, host := range []struct {
+		url   string
+		limit HostRateLimit
+	}{{iamURL, limits.IAM}, {cx1URL, limits.Cx1}} {
+		if host.limit.RequestsPerSecond <= 0 {
+			continue
+		}
+		u, err := url.Parse(host.url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %v to apply the rate limit: %s", host.url, err)
+		}
+		r.buckets[u.Host] = newTokenBucket(host.limit)
+		logger.Infof("Limiting requests to %v to %g per second", u.Host, host.limit.RequestsPerSecond)
+	}
+	return r, nil
+}
+
+func (r *RateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	bucket := r.buckets[req.URL.Host]
+	for attempt := 0; ; attempt++ {
+		if bucket != nil {
+			if err := r.wait(req.Context(), bucket.reserve()); err != nil {
+				return nil, err
+			}
+		}
+
+		response, err := r.next.RoundTrip(req)
+		if err != nil || response.StatusCode != http.StatusTooManyRequests {
+			return response, err
+		}
+
+		r.lock.Lock()
+		r.rejected++
+		r.lock.Unlock()
+		if attempt >= r.maxRetries {
+			return response, nil
+		}
+		if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+			return response, nil // the body was read and can not be sent again
+		}
+
+		delay := getRetryAfter(response.Header.Get("Retry-After"), attempt)
+		_, _ = io.Copy(io.Discard, response.Body)
+		response.Body.Close()
+		r.logger.Debugf("Request %v %v was rejected with 429 Too Many Requests, sending it again in %v", req.Method, req.URL.Path, delay)
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		if err := r.wait(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (r *RateLimiter) wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	start := time.Now()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+	}
+
+	r.lock.Lock()
+	r.throttled += time.Since(start)
+	r.lock.Unlock()
+	return err
+}
+
+// Retry-After is either a number of seconds or a date, without it the delay doubles with each attempt starting from 1 second
+func getRetryAfter(header string, attempt int) time.Duration {
+	delay := maxRetryAfter
+	if attempt < 16 {
+		delay = time.Second << attempt
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		delay = time.Duration(math.Min(float64(seconds), maxRetryAfter.Seconds()) * float64(time.Second))
+	} else if date, err := http.ParseTime(header); err == nil {
+		delay = time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+	}
+
+	if delay > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return delay
+}
+
+// seconds which requests spent waiting for the rate limit or for Retry-After, 0 without a rate limiter
+func (r *RateLimiter) Throttled() float64 {
+	if r == nil {
+		return 0
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.throttled.Seconds()
+}
+
+// number of responses with 429 Too Many Requests, 0 without a rate limiter
+func (r *RateLimiter) Rejected() int {
+	if r == nil {
+		return 0
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.rejected
+}
+
+// eg: requests waited 12.5 seconds for the rate limit, responses with 429 Too Many Requests: 2 - empty if the run was not throttled
+func (s ReportSettings) throttling() string {
+	if s.Throttled == 0 && s.Rejected == 0 {
+		return ""
+	}
+	return fmt.Sprintf("requests waited %.1f seconds for the rate limit, responses with 429 Too Many Requests: %d", s.Throttled, s.Rejected)
+}
@@ -0,0 +1,84 @@
+package process
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestGetRetryAfter(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		attempt int
+		delay   time.Duration
+	}{
+		{"no header, first attempt", "", 0, time.Second},
+		{"no header doubles", "", 3, 8 * time.Second},
+		{"no header is limited", "", 10, maxRetryAfter},
+		{"no header after many attempts", "", 100, maxRetryAfter},
+		{"seconds", "7", 0, 7 * time.Second},
+		{"seconds replace the backoff", "2", 5, 2 * time.Second},
+		{"zero seconds", "0", 2, 0},
+		{"seconds are limited", "3600", 0, maxRetryAfter},
+		{"negative seconds are ignored", "-5", 1, 2 * time.Second},
+		{"invalid header is ignored", "soon", 2, 4 * time.Second},
+		{"date in the past", "Mon, 02 Jan 2006 15:04:05 GMT", 0, 0},
+		{"date far in the future", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 0, maxRetryAfter},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if delay := getRetryAfter(test.header, test.attempt); delay != test.delay {
+				t.Errorf("expected %v, got %v", test.delay, delay)
+			}
+		})
+	}
+}
+
+type rejectingTransport struct {
+	requests int
+}
+
+func (r *rejectingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.requests++
+	return &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"0"}}, Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func TestRateLimiterMaxRetries(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	retries := func(count int) *int { return &count }
+
+	tests := []struct {
+		name       string
+		maxRetries *int
+		requests   int
+	}{
+		{"default", nil, defaultRateLimitRetries + 1},
+		{"no retries", retries(0), 1},
+		{"one retry", retries(1), 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transport := &rejectingTransport{}
+			limiter, err := NewRateLimiter(transport, RateLimits{MaxRetries: test.maxRetries}, "https://cx1.example", "https://iam.example", logger)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			req, _ := http.NewRequest(http.MethodGet, "https://cx1.example/api/projects", nil)
+			response, err := limiter.RoundTrip(req)
+			if err != nil || response.StatusCode != http.StatusTooManyRequests {
+				t.Fatalf("expected the last rejection to be returned, got %v, %v", response, err)
+			}
+			if transport.requests != test.requests || limiter.Rejected() != test.requests {
+				t.Errorf("expected %d requests, got %d and %d rejected", test.requests, transport.requests, limiter.Rejected())
+			}
+		})
+	}
+}
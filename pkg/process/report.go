@@ -3,7 +3,9 @@ package process
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
@@ -11,21 +13,93 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// report formats, Config.ReportType is a comma-separated list of these
+const (
+	REPORT_HTML     = "html"
+	REPORT_JSON     = "json"
+	REPORT_JUNIT    = "junit"
+	REPORT_MARKDOWN = "markdown"
+)
+
+var knownReportTypes = []string{REPORT_HTML, REPORT_JSON, REPORT_JUNIT, REPORT_MARKDOWN}
+
+// checks that the comma-separated report types are known, eg: html,junit
+func ValidateReportType(reportType string) error {
+	for _, t := range strings.Split(reportType, ",") {
+		if t = strings.TrimSpace(t); t != "" && !containsString(knownReportTypes, t) {
+			return fmt.Errorf("unknown report type '%v', supported types are: %v", t, strings.Join(knownReportTypes, ", "))
+		}
+	}
+	return nil
+}
+
+func (c *TestConfig) HasReportType(reportType string) bool {
+	return hasReportType(c.ReportType, reportType)
+}
+
+func hasReportType(reportTypes, reportType string) bool {
+	for _, t := range strings.Split(reportTypes, ",") {
+		if strings.EqualFold(strings.TrimSpace(t), reportType) {
+			return true
+		}
+	}
+	return false
+}
+
 func prepareReportData(tests *[]TestResult, Config *TestConfig) Report {
 	var report Report
-	report.Settings.Target = fmt.Sprintf("%v tenant %v", Config.Cx1URL, Config.Tenant)
-	report.Settings.Auth = fmt.Sprintf("%v user %v", Config.AuthType, Config.AuthUser)
-	report.Settings.Config = Config.ConfigPath
-	report.Settings.Timestamp = time.Now().String()
-	report.Settings.E2ESuffix = os.Getenv("E2E_RUN_SUFFIX")
+	report.Summary.Area = make(map[string]*CounterSet)
+	for _, module := range GetModules() {
+		report.Summary.Area[module.Area] = &CounterSet{}
+	}
+	report.Settings = Config.getReportSettings()
 
 	for _, r := range *tests {
-		report.AddTest(&r)
+		if r.Phase == PHASE_TEARDOWN { // reported separately so that cleanup problems do not hide the results of the tests
+			report.Summary.Teardown.AddTest(&r)
+			report.Teardown = append(report.Teardown, makeTestDetails(&r))
+		} else {
+			report.AddTest(&r)
+		}
+	}
+
+	if Config.state != nil {
+		for _, r := range Config.state.teardownResults {
+			report.AutoTeardown = append(report.AutoTeardown, makeTestDetails(&r))
+		}
+		report.Variables = Config.state.GetVariables()
+		report.Hooks = Config.state.GetHookResults()
 	}
+	report.QualityGate = Config.EvaluateQualityGate(*tests)
 
 	return report
 }
 
+// the settings of the run which are shown at the top of every report
+func (c *TestConfig) getReportSettings() ReportSettings {
+	now := time.Now()
+	settings := ReportSettings{
+		Target:    fmt.Sprintf("%v tenant %v", c.Cx1URL, c.Tenant),
+		Auth:      fmt.Sprintf("%v user %v", c.AuthType, c.AuthUser),
+		Config:    c.ConfigPath,
+		Timestamp: now.String(),
+		started:   now,
+		E2ESuffix: os.Getenv("E2E_RUN_SUFFIX"),
+		Env:       c.Environment,
+		Throttled: c.RateLimiter.Throttled(),
+		Rejected:  c.RateLimiter.Rejected(),
+		Version:   c.EnvironmentVersion,
+	}
+	if c.Resume != nil {
+		settings.Resumed = fmt.Sprintf("checkpoint of %v", c.Resume.Timestamp)
+	}
+	if c.state != nil && c.state.abortReason != "" {
+		settings.Aborted = true
+		settings.AbortReason = c.state.abortReason
+	}
+	return settings
+}
+
 func (c *Counter) AddTest(t *TestResult) {
 	switch t.Result {
 	case TST_PASS:
@@ -34,6 +108,8 @@ func (c *Counter) AddTest(t *TestResult) {
 		c.Fail++
 	case TST_SKIP:
 		c.Skip++
+	case TST_SLOW:
+		c.Slow++
 	}
 }
 
@@ -51,48 +127,26 @@ func (c *CounterSet) AddTest(t *TestResult) {
 }
 
 func (s *ReportSummary) AddTest(t *TestResult) {
-	switch t.Module {
-	case types.MOD_ACCESS:
-		s.Area.Access.AddTest(t)
-	case types.MOD_APPLICATION:
-		s.Area.Application.AddTest(t)
-	case types.MOD_FLAG:
-		s.Area.Flag.AddTest(t)
-	case types.MOD_GROUP:
-		s.Area.Group.AddTest(t)
-	case types.MOD_IMPORT:
-		s.Area.Import.AddTest(t)
-	case types.MOD_PRESET:
-		s.Area.Preset.AddTest(t)
-	case types.MOD_PROJECT:
-		s.Area.Project.AddTest(t)
-	case types.MOD_QUERY:
-		s.Area.Query.AddTest(t)
-	case types.MOD_RESULT:
-		s.Area.Result.AddTest(t)
-	case types.MOD_REPORT:
-		s.Area.Report.AddTest(t)
-	case types.MOD_ROLE:
-		s.Area.Role.AddTest(t)
-	case types.MOD_SCAN:
-		s.Area.Scan.AddTest(t)
-	case types.MOD_USER:
-		s.Area.User.AddTest(t)
+	area := t.Module
+	if module, ok := GetModuleByName(t.Module); ok {
+		area = module.Area
 	}
-
-	switch t.Result {
-	case TST_PASS:
-		s.Total.Pass++
-	case TST_SKIP:
-		s.Total.Skip++
-	case TST_FAIL:
-		s.Total.Fail++
+	if s.Area == nil {
+		s.Area = make(map[string]*CounterSet)
 	}
+	if s.Area[area] == nil {
+		s.Area[area] = &CounterSet{}
+	}
+	s.Area[area].AddTest(t)
+	s.Total.AddTest(t)
 }
 
 func (r *Report) AddTest(t *TestResult) {
 	r.Summary.AddTest(t)
+	r.Details = append(r.Details, makeTestDetails(t))
+}
 
+func makeTestDetails(t *TestResult) ReportTestDetails {
 	testtype := "Test"
 	if t.FailTest {
 		testtype = "Negative-Test"
@@ -104,6 +158,15 @@ func (r *Report) AddTest(t *TestResult) {
 		Test:       fmt.Sprintf("%v %v %v: %v", t.CRUD, t.Module, testtype, t.TestObject),
 		Duration:   t.Duration,
 		ResultType: t.Result,
+		Phase:      t.Phase,
+		Module:     t.Module,
+		CRUD:       t.CRUD,
+	}
+	if t.Result != TST_PASS {
+		details.Reason = t.Reason
+	}
+	if t.Phase != "" {
+		details.Test = fmt.Sprintf("[%v] %v", t.Phase, details.Test)
 	}
 
 	switch t.Result {
@@ -113,9 +176,16 @@ func (r *Report) AddTest(t *TestResult) {
 		details.Result = fmt.Sprintf("FAIL: %v", t.Reason)
 	case TST_SKIP:
 		details.Result = fmt.Sprintf("SKIP: %v", t.Reason)
+	case TST_SLOW:
+		details.Result = fmt.Sprintf("SLOW: %v", t.Reason)
 	}
 
-	r.Details = append(r.Details, details)
+	if len(t.Attempts) > 1 {
+		details.Attempts = t.Attempts
+		details.Result = fmt.Sprintf("%v (after %d attempts)", details.Result, len(t.Attempts))
+	}
+
+	return details
 }
 
 func (d ReportTestDetails) String() string {
@@ -125,8 +195,13 @@ func (d ReportTestDetails) String() string {
 		result = "FAIL"
 	case TST_SKIP:
 		result = "SKIP"
+	case TST_SLOW:
+		result = "SLOW"
 	}
 
+	if len(d.Attempts) > 1 {
+		return fmt.Sprintf("%v %v - %v (%d attempts)", result, d.Name, d.Test, len(d.Attempts))
+	}
 	return fmt.Sprintf("%v %v - %v", result, d.Name, d.Test)
 }
 
@@ -137,16 +212,51 @@ func OutputSummaryConsole(reportData *Report, logger *logrus.Logger) {
 	}
 
 	fmt.Println("")
-	fmt.Printf("Ran %d tests\n", (reportData.Summary.Total.Fail + reportData.Summary.Total.Pass + reportData.Summary.Total.Skip))
+	fmt.Printf("Ran %d tests\n", (reportData.Summary.Total.Fail + reportData.Summary.Total.Pass + reportData.Summary.Total.Skip + reportData.Summary.Total.Slow))
 	if reportData.Summary.Total.Fail > 0 {
 		fmt.Printf("FAILED %d tests\n", reportData.Summary.Total.Fail)
 	}
+	if reportData.Summary.Total.Slow > 0 {
+		fmt.Printf("SLOW %d tests\n", reportData.Summary.Total.Slow)
+	}
 	if reportData.Summary.Total.Skip > 0 {
 		fmt.Printf("SKIPPED %d tests\n", reportData.Summary.Total.Skip)
 	}
 	if reportData.Summary.Total.Pass > 0 {
 		fmt.Printf("PASSED %d tests\n", reportData.Summary.Total.Pass)
 	}
+	if reportData.Settings.Aborted {
+		fmt.Printf("ABORTED: %v, tests which did not run were skipped\n", reportData.Settings.AbortReason)
+	}
+	if throttling := reportData.Settings.throttling(); throttling != "" {
+		fmt.Printf("Throttled: %v\n", throttling)
+	}
+
+	if len(reportData.Teardown) > 0 {
+		fmt.Println("")
+		fmt.Println("Teardown:")
+		for _, r := range reportData.Teardown {
+			fmt.Println(r.String())
+		}
+	}
+
+	if len(reportData.AutoTeardown) > 0 {
+		fmt.Println("")
+		fmt.Println("Automatic teardown:")
+		for _, r := range reportData.AutoTeardown {
+			fmt.Println(r.String())
+		}
+	}
+
+	for _, h := range reportData.Hooks {
+		if h.Result == "FAIL" {
+			fmt.Printf("FAILED hook %v\n", h.String())
+		}
+	}
+
+	if reportData.QualityGate != nil {
+		outputQualityGateConsole(reportData.QualityGate)
+	}
 
 }
 
@@ -164,10 +274,22 @@ func OutputReportHTML(reportName string, reportData *Report, Config *TestConfig)
 
 	report.WriteString("<h2>Settings</h2>")
 	report.WriteString(fmt.Sprintf("Running end to end tests against %v<br>", reportData.Settings.Target))
+	if reportData.Settings.Env != "" {
+		report.WriteString(fmt.Sprintf("Environment: %v<br>", reportData.Settings.Env))
+	}
 	report.WriteString(fmt.Sprintf("Target versions are: %v", reportData.Settings.Version.String()))
 	report.WriteString(fmt.Sprintf("Authenticated using %v<br>", reportData.Settings.Auth))
 	report.WriteString(fmt.Sprintf("Test set defined in configuration %v<br>", reportData.Settings.Config))
 	report.WriteString(fmt.Sprintf("Execution timestamp: %v.<br>", reportData.Settings.Timestamp))
+	if reportData.Settings.Resumed != "" {
+		report.WriteString(fmt.Sprintf("Resumed from %v.<br>", reportData.Settings.Resumed))
+	}
+	if reportData.Settings.Aborted {
+		report.WriteString(fmt.Sprintf("<span style='color:red'>Aborted: %v, tests which did not run were skipped.</span><br>", reportData.Settings.AbortReason))
+	}
+	if throttling := reportData.Settings.throttling(); throttling != "" {
+		report.WriteString(fmt.Sprintf("Throttled: %v.<br>", throttling))
+	}
 	if os.Getenv("E2E_RUN_SUFFIX") == "" {
 		report.WriteString(fmt.Sprintf("Default object name suffix %%E2E_RUN_SUFFIX%% environment variable is blank. Objects created by cx1e2e will use default names.<br>"))
 	} else {
@@ -176,40 +298,41 @@ func OutputReportHTML(reportName string, reportData *Report, Config *TestConfig)
 
 	report.WriteString("<h2>Summary</h2>")
 
-	report.WriteString(fmt.Sprintf("<p>Test status:<br>FAIL: %d<br>SKIP: %d<br>PASS:%d<br></p>", reportData.Summary.Total.Fail, reportData.Summary.Total.Skip, reportData.Summary.Total.Pass))
-
-	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th rowspan=2>Area</th><th colspan=3>Create</th><th colspan=3>Read</th><th colspan=3>Update</th><th colspan=3>Delete</th></tr>\n")
-	report.WriteString("<tr><th>Pass</th><th>Fail</th><th>Skip</th><th>Pass</th><th>Fail</th><th>Skip</th><th>Pass</th><th>Fail</th><th>Skip</th><th>Pass</th><th>Fail</th><th>Skip</th></tr>\n")
-	writeCounterSet(report, "Access Assignment", &reportData.Summary.Area.Access)
-	writeCounterSet(report, "Application", &reportData.Summary.Area.Application)
-	writeCounterSet(report, "Flag", &reportData.Summary.Area.Flag)
-	writeCounterSet(report, "Group", &reportData.Summary.Area.Group)
-	writeCounterSet(report, "Import", &reportData.Summary.Area.Import)
-	writeCounterSet(report, "Preset", &reportData.Summary.Area.Preset)
-	writeCounterSet(report, "Project", &reportData.Summary.Area.Project)
-	writeCounterSet(report, "Query", &reportData.Summary.Area.Query)
-	writeCounterSet(report, "Result", &reportData.Summary.Area.Result)
-	writeCounterSet(report, "Report", &reportData.Summary.Area.Report)
-	writeCounterSet(report, "Role", &reportData.Summary.Area.Role)
-	writeCounterSet(report, "Scan", &reportData.Summary.Area.Scan)
-	writeCounterSet(report, "User", &reportData.Summary.Area.User)
+	report.WriteString(fmt.Sprintf("<p>Test status:<br>FAIL: %d<br>SKIP: %d<br>SLOW: %d<br>PASS:%d<br></p>", reportData.Summary.Total.Fail, reportData.Summary.Total.Skip, reportData.Summary.Total.Slow, reportData.Summary.Total.Pass))
+
+	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th rowspan=2>Area</th><th colspan=4>Create</th><th colspan=4>Read</th><th colspan=4>Update</th><th colspan=4>Delete</th></tr>\n")
+	report.WriteString("<tr>" + strings.Repeat("<th>Pass</th><th>Slow</th><th>Fail</th><th>Skip</th>", 4) + "</tr>\n")
+	modules := GetModules()
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Title < modules[j].Title })
+	for _, module := range modules {
+		writeCounterSet(report, module.Title, reportData.Summary.Area[module.Area])
+	}
 	report.WriteString("</table><br>")
 
+	if reportData.QualityGate != nil {
+		report.WriteString("<h2>Quality gate</h2>")
+		writeQualityGate(report, reportData.QualityGate)
+	}
+
 	report.WriteString("<h2>Details</h2>")
-	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th>Test Set</th><th>Test</th><th>Duration (sec)</th><th>Result</th></tr>\n")
+	writeDetailsTable(report, reportData.Details)
 
-	for _, t := range reportData.Details {
-		switch t.ResultType {
-		case TST_PASS:
-			report.WriteString(fmt.Sprintf("<tr><td>%v<br>(%v)</td><td>%v</td><td>%.2f</td><td><span style='color:green'>%v</span></td></tr>\n", t.Name, t.Source, t.Test, t.Duration, t.Result))
-		case TST_SKIP:
-			report.WriteString(fmt.Sprintf("<tr><td>%v<br>(%v)</td><td>%v</td><td>%.2f</td><td><span style='color:orange'>%v</span></td></tr>\n", t.Name, t.Source, t.Test, t.Duration, t.Result))
-		case TST_FAIL:
-			report.WriteString(fmt.Sprintf("<tr><td>%v<br>(%v)</td><td>%v</td><td>%.2f</td><td><span style='color:red'>%v</span></td></tr>\n", t.Name, t.Source, t.Test, t.Duration, t.Result))
-		}
+	if len(reportData.Teardown) > 0 {
+		report.WriteString("<h2>Teardown</h2>")
+		report.WriteString(fmt.Sprintf("<p>Teardown blocks of the test sets: FAIL: %d, SKIP: %d, SLOW: %d, PASS: %d</p>", reportData.Summary.Teardown.Fail, reportData.Summary.Teardown.Skip, reportData.Summary.Teardown.Slow, reportData.Summary.Teardown.Pass))
+		writeDetailsTable(report, reportData.Teardown)
 	}
 
-	report.WriteString("</table>\n")
+	if len(reportData.AutoTeardown) > 0 {
+		report.WriteString("<h2>Automatic teardown</h2>")
+		report.WriteString("<p>Objects created during this run which were not deleted by a test.</p>")
+		writeDetailsTable(report, reportData.AutoTeardown)
+	}
+
+	if len(reportData.Hooks) > 0 {
+		report.WriteString("<h2>Hooks</h2>")
+		writeHooksTable(report, reportData.Hooks)
+	}
 
 	_, err = report.WriteString("</body></html>")
 	if err != nil {
@@ -239,36 +362,144 @@ func OutputReportJSON(reportName string, reportData *Report) error {
 	return report.Sync()
 }
 
-func GenerateReport(tests *[]TestResult, logger *logrus.Logger, Config *TestConfig) (float32, error) {
+// writes the reports configured in Config.ReportType, returns the report data and the first error writing a report
+func GenerateReport(tests *[]TestResult, logger *logrus.Logger, Config *TestConfig) (Report, error) {
+	var reportErr error
 	reportData := prepareReportData(tests, Config)
 	OutputSummaryConsole(&reportData, logger)
 
-	if strings.Contains(Config.ReportType, "html") {
+	if Config.HasReportType(REPORT_HTML) {
 		err := OutputReportHTML(fmt.Sprintf("%v.html", Config.ReportName), &reportData, Config)
 		if err != nil {
 			logger.Errorf("Failed to write HTML report to %v.html: %s", Config.ReportName, err)
+			reportErr = fmt.Errorf("failed to write HTML report to %v.html: %s", Config.ReportName, err)
 		}
 	}
 
-	if strings.Contains(Config.ReportType, "json") {
+	if Config.HasReportType(REPORT_JSON) {
 		err := OutputReportJSON(fmt.Sprintf("%v.json", Config.ReportName), &reportData)
 		if err != nil {
 			logger.Errorf("Failed to write JSON report to %v.json: %s", Config.ReportName, err)
+			if reportErr == nil {
+				reportErr = fmt.Errorf("failed to write JSON report to %v.json: %s", Config.ReportName, err)
+			}
+		}
+	}
+
+	if Config.HasReportType(REPORT_JUNIT) {
+		err := OutputReportJUnit(fmt.Sprintf("%v.xml", Config.ReportName), &reportData)
+		if err != nil {
+			logger.Errorf("Failed to write JUnit report to %v.xml: %s", Config.ReportName, err)
+			if reportErr == nil {
+				reportErr = fmt.Errorf("failed to write JUnit report to %v.xml: %s", Config.ReportName, err)
+			}
+		}
+	}
+
+	if Config.HasReportType(REPORT_MARKDOWN) {
+		err := OutputReportMarkdown(fmt.Sprintf("%v.md", Config.ReportName), &reportData)
+		if err != nil {
+			logger.Errorf("Failed to write Markdown report to %v.md: %s", Config.ReportName, err)
+			if reportErr == nil {
+				reportErr = fmt.Errorf("failed to write Markdown report to %v.md: %s", Config.ReportName, err)
+			}
+		}
+	}
+
+	if Config.StepSummary {
+		if err := writeStepSummary(&reportData, logger); err != nil {
+			logger.Errorf("Failed to write the step summary: %s", err)
+			if reportErr == nil {
+				reportErr = fmt.Errorf("failed to write the step summary: %s", err)
+			}
 		}
 	}
 
-	status := float32(reportData.Summary.Total.Pass) / float32(reportData.Summary.Total.Skip+reportData.Summary.Total.Fail+reportData.Summary.Total.Pass)
+	return reportData, reportErr
+}
 
-	return status, nil
+// the share of tests which passed, slow tests count as passed unless slowFails is set
+// a run without any tests has not passed
+func (s *ReportSummary) GetStatus(slowFails bool) float32 {
+	total := s.Total.Skip + s.Total.Fail + s.Total.Pass + s.Total.Slow
+	if total == 0 {
+		return 0
+	}
+	passed := s.Total.Pass
+	if !slowFails {
+		passed += s.Total.Slow
+	}
+	return float32(passed) / float32(total)
+}
+
+func writeDetailsTable(report *os.File, details []ReportTestDetails) {
+	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th>Test Set</th><th>Test</th><th>Duration (sec)</th><th>Result</th></tr>\n")
+
+	for _, t := range details {
+		switch t.ResultType {
+		case TST_PASS:
+			report.WriteString(fmt.Sprintf("<tr><td>%v<br>(%v)</td><td>%v</td><td>%.2f</td><td><span style='color:green'>%v</span>%v</td></tr>\n", t.Name, t.Source, t.Test, t.Duration, t.Result, attemptsHTML(t.Attempts)))
+		case TST_SKIP:
+			report.WriteString(fmt.Sprintf("<tr><td>%v<br>(%v)</td><td>%v</td><td>%.2f</td><td><span style='color:orange'>%v</span>%v</td></tr>\n", t.Name, t.Source, t.Test, t.Duration, t.Result, attemptsHTML(t.Attempts)))
+		case TST_FAIL:
+			report.WriteString(fmt.Sprintf("<tr><td>%v<br>(%v)</td><td>%v</td><td>%.2f</td><td><span style='color:red'>%v</span>%v</td></tr>\n", t.Name, t.Source, t.Test, t.Duration, t.Result, attemptsHTML(t.Attempts)))
+		case TST_SLOW:
+			report.WriteString(fmt.Sprintf("<tr><td>%v<br>(%v)</td><td>%v</td><td><span style='color:darkorange'>%.2f</span></td><td><span style='color:darkorange'>%v</span>%v</td></tr>\n", t.Name, t.Source, t.Test, t.Duration, t.Result, attemptsHTML(t.Attempts)))
+		}
+	}
+
+	report.WriteString("</table>\n")
+}
+
+func writeHooksTable(report *os.File, hooks []HookResult) {
+	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th>Hook</th><th>Test</th><th>Command</th><th>Duration (sec)</th><th>Result</th></tr>\n")
+
+	for _, h := range hooks {
+		test := h.Set
+		if h.Test != "" {
+			test = fmt.Sprintf("%v<br>%v", h.Set, h.Test)
+		}
+		result := "<span style='color:green'>PASS</span>"
+		if h.Result == "FAIL" {
+			result = fmt.Sprintf("<span style='color:red'>FAIL</span>: %v", html.EscapeString(h.Reason))
+		}
+		if h.Output != "" {
+			result += fmt.Sprintf("<pre style='color:gray'>%v</pre>", html.EscapeString(h.Output))
+		}
+		report.WriteString(fmt.Sprintf("<tr><td>%v</td><td>%v</td><td><code>%v</code></td><td>%.2f</td><td>%v</td></tr>\n", h.Hook, test, html.EscapeString(h.Command), h.Duration, result))
+	}
+
+	report.WriteString("</table>\n")
+}
+
+func attemptsHTML(attempts []TestAttempt) string {
+	if len(attempts) <= 1 {
+		return ""
+	}
+	str := "<ol style='color:gray'>"
+	for _, a := range attempts {
+		if a.Reason == "" {
+			str += fmt.Sprintf("<li>[%.2fs] PASS</li>", a.Duration)
+		} else {
+			str += fmt.Sprintf("<li>[%.2fs] %v</li>", a.Duration, a.Reason)
+		}
+	}
+	return str + "</ol>"
 }
 
 func writeCell(report *os.File, count uint, good bool) {
+	if good {
+		writeColorCell(report, count, "green")
+	} else {
+		writeColorCell(report, count, "red")
+	}
+}
+
+func writeColorCell(report *os.File, count uint, color string) {
 	if count == 0 {
 		report.WriteString("<td>&nbsp;</td>")
-	} else if good {
-		report.WriteString(fmt.Sprintf("<td style='color:green;text-align:center;'>%d</td>", count))
 	} else {
-		report.WriteString(fmt.Sprintf("<td style='color:red;text-align:center;'>%d</td>", count))
+		report.WriteString(fmt.Sprintf("<td style='color:%v;text-align:center;'>%d</td>", color, count))
 	}
 }
 
@@ -276,15 +507,19 @@ func writeCounterSet(report *os.File, module string, count *CounterSet) {
 	report.WriteString(fmt.Sprintf("<tr><td>%v</td>", module))
 
 	writeCell(report, count.Create.Pass, true)
+	writeColorCell(report, count.Create.Slow, "darkorange")
 	writeCell(report, count.Create.Fail, false)
 	writeCell(report, count.Create.Skip, false)
 	writeCell(report, count.Read.Pass, true)
+	writeColorCell(report, count.Read.Slow, "darkorange")
 	writeCell(report, count.Read.Fail, false)
 	writeCell(report, count.Read.Skip, false)
 	writeCell(report, count.Update.Pass, true)
+	writeColorCell(report, count.Update.Slow, "darkorange")
 	writeCell(report, count.Update.Fail, false)
 	writeCell(report, count.Update.Skip, false)
 	writeCell(report, count.Delete.Pass, true)
+	writeColorCell(report, count.Delete.Slow, "darkorange")
 	writeCell(report, count.Delete.Fail, false)
 	writeCell(report, count.Delete.Skip, false)
 
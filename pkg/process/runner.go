@@ -1,7 +1,10 @@
 package process
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
@@ -14,23 +17,62 @@ const (
 	TST_FAIL = 0
 	TST_PASS = 1
 	TST_SKIP = 2
+	TST_SLOW = 3 // passed, but took longer than its MaxDuration
 )
 
+// how long a test may take to return after it was canceled, before the runner stops waiting for it
+const cancelGracePeriod = 30 * time.Second
+
 type TestRunner interface {
-	Validate(testType string) error
+	Validate(ctx context.Context, testType string) error
 	String() string
 	IsType(testType string) bool
 	IsForced() bool
-	IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, testType string, Engines *types.EnabledEngines) error
+	IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, testType string, Engines *types.EnabledEngines) error
 	IsNegative() bool
 	GetSource() string
+	SetSource(source string)
 	GetModule() string
 	GetFlags() []string
+	GetDependencies() []string
+	GetRetry() *types.RetryPolicy
+	GetTimeout() int
+	GetTags() []string
+	GetTestType() string
+	GetCaptures() map[string]string
+	GetExpectations() []types.Assertion
+	GetMaxDuration() float64
+
+	// ctx is canceled when the test times out or the run stops, requests to Cx1 can not be canceled so the tests check it while polling
+	// and before the next step which changes anything in Cx1 - a test which does not return within the grace period is abandoned
+	RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines, Registry *types.CleanupRegistry) error
+	RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines) error
+	RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines) error
+	RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines, Registry *types.CleanupRegistry) error
+}
+
+// implemented by tests which refer to files, which are then located relative to the config file
+type FileReferencer interface {
+	ResolveFiles(locate func(file string) (string, error)) error
+}
+
+// implemented by tests which can leave work running in Cx1 when they are aborted, eg: a scan
+type InFlightCanceler interface {
+	CancelInFlight(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger) error
+}
+
+// implemented by tests which may need longer than the grace period to clean up after being canceled, eg: to cancel a scan
+type CancelWaiter interface {
+	GetCancelTimeout() time.Duration
+}
+
+// returned when a test was aborted because it, or the whole run, took too long or was interrupted
+type timeoutError struct {
+	reason string
+}
 
-	RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines) error
-	RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines) error
-	RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines) error
-	RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *types.EnabledEngines) error
+func (e timeoutError) Error() string {
+	return e.reason
 }
 
 func MakeResult(test TestRunner) TestResult {
@@ -42,107 +84,297 @@ func MakeResult(test TestRunner) TestResult {
 		Id:         -1,
 		TestObject: test.String(),
 		TestSource: test.GetSource(),
+		Tags:       test.GetTags(),
 	}
 }
 
-func RunTests(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Config *TestConfig) float32 {
-	all_results := []TestResult{}
+func SkipResult(test TestRunner, CRUD, testName, reason string) TestResult {
+	result := MakeResult(test)
+	result.CRUD = CRUD
+	result.Name = testName
+	result.Reason = reason
+	result.Result = TST_SKIP
+	return result
+}
 
-	for id := range Config.Tests {
-		all_results = append(all_results, Config.Tests[id].RunTests(cx1client, logger, Config)...)
+// runs the tests and returns the share of tests which passed, see Execute
+func RunTests(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Config *TestConfig) float32 {
+	result, err := Execute(ctx, cx1client, logger, Config)
+	if err != nil {
+		logger.Errorf("%s", err)
+	}
+	if result == nil {
+		return 0
 	}
+	return result.Status
+}
 
-	status, err := GenerateReport(&all_results, logger, Config)
-	if err != nil {
-		logger.Errorf("Failed to generate the report: %s", err)
+// consecutive test sets marked as Parallel are grouped into a single batch when running with concurrency,
+// unless a set depends on another set within the same batch
+func (c *TestConfig) getBatches() [][]int {
+	batches := [][]int{}
+	for id := range c.Tests {
+		last := len(batches) - 1
+		if c.Concurrency > 1 && c.Tests[id].Parallel && last >= 0 && c.Tests[batches[last][0]].Parallel && !c.dependsOnAny(id, batches[last]) {
+			batches[last] = append(batches[last], id)
+		} else {
+			batches = append(batches, []int{id})
+		}
 	}
+	return batches
+}
 
-	return status
+func (c *TestConfig) dependsOnAny(setID int, batch []int) bool {
+	dependencies := c.Tests[setID].GetAllDependencies()
+	for _, id := range batch {
+		for _, dep := range dependencies {
+			if c.Tests[id].HasName(dep) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-func (t *TestSet) RunTests(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Config *TestConfig) []TestResult {
-	logger.Tracef("Running test set: %v", t.Name)
+func (t *TestSet) HasTests() bool {
+	return hasTests(t.GetModuleTests())
+}
 
-	if t.Wait > 0 {
-		logger.Infof("Waiting for %d seconds", t.Wait)
-		time.Sleep(time.Duration(t.Wait) * time.Second)
+func hasTests(moduleTests [][]TestRunner) bool {
+	for _, tests := range moduleTests {
+		if len(tests) > 0 {
+			return true
+		}
 	}
+	return false
+}
 
-	all_results := []TestResult{}
+// the dependencies of the set itself and of all of its tests
+func (t *TestSet) GetAllDependencies() []string {
+	dependencies := append([]string{}, t.DependsOn...)
+	for _, tests := range t.GetAllModuleTests() {
+		for _, test := range tests {
+			dependencies = append(dependencies, test.GetDependencies()...)
+		}
+	}
+	return dependencies
+}
 
-	all_results = append(all_results, t.Run(cx1client, logger, types.OP_CREATE, Config)...)
-	all_results = append(all_results, t.Run(cx1client, logger, types.OP_READ, Config)...)
-	all_results = append(all_results, t.Run(cx1client, logger, types.OP_UPDATE, Config)...)
-	all_results = append(all_results, t.Run(cx1client, logger, types.OP_DELETE, Config)...)
+func (t *TestSet) RunTests(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Config *TestConfig) []TestResult {
+	logger.Tracef("Running test set: %v", t.Name)
+	Config.state.events.Emit(SetStarted{Set: t.Name})
 
-	return all_results
-}
+	if ctx.Err() != nil {
+		return Config.state.finished(t.SkipTests(logger, getStopReason(ctx)))
+	}
 
-func (t *TestSet) Run(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Config *TestConfig) []TestResult {
-	results := []TestResult{}
+	if reason := Config.state.GetDependencyFailure(t.DependsOn); reason != "" {
+		logger.Warnf("Test set '%v' will be skipped: %v", t.Name, reason)
+		Config.state.SetFailed(t, reason)
+		return Config.state.finished(t.SkipTests(logger, reason))
+	}
 
-	for id := range t.Flags {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Flags[id]), &results, Config)
+	idle := "" // why none of the tests in the set will run
+	if Config.IsFiltered() && !Config.HasSelectedTests(t) {
+		idle = "none of its tests are selected"
+	} else if Config.Resume != nil && t.HasTests() && Config.state.checkpoint.IsSetCompleted(t) {
+		idle = "it was completed before the run was resumed"
 	}
-	for id := range t.Imports {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Imports[id]), &results, Config)
+
+	if t.Wait > 0 && idle != "" {
+		logger.Debugf("Not waiting for test set '%v' since %v", t.Name, idle)
+	} else if t.Wait > 0 {
+		logger.Infof("Waiting for %d seconds", t.Wait)
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Duration(t.Wait) * time.Second):
+		}
 	}
-	for id := range t.Groups {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Groups[id]), &results, Config)
+
+	hc := hookContext{Set: t.Name}
+	if idle == "" {
+		if err := Config.runHooks(ctx, logger, HOOK_BEFORE_SET, t, hc); err != nil {
+			Config.state.SetFailed(t, err.Error())
+			return Config.state.finished(t.FailTests(logger, err.Error()))
+		}
 	}
-	for id := range t.Applications {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Applications[id]), &results, Config)
+
+	all_results := t.RunPhase(ctx, cx1client, logger, PHASE_SETUP, Config)
+	failure := getFailure(all_results)
+	if failure != "" {
+		reason := fmt.Sprintf("setup failed: %v", failure)
+		logger.Warnf("The tests of test set '%v' will be skipped: %v", t.Name, reason)
+		all_results = append(all_results, Config.state.finished(t.endPhase(logger, PHASE_TEST, TST_SKIP, reason))...)
+	} else {
+		all_results = append(all_results, t.RunPhase(ctx, cx1client, logger, PHASE_TEST, Config)...)
+		failure = getFailure(all_results)
 	}
-	for id := range t.Projects {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Projects[id]), &results, Config)
+	if failure != "" {
+		Config.state.SetFailed(t, failure)
 	}
-	for id := range t.Roles {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Roles[id]), &results, Config)
+
+	// the teardown also runs when the run was interrupted, since objects created by the set would be left behind otherwise
+	teardownCtx := ctx
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		teardownCtx, cancel = Config.teardownContext(logger)
+		defer cancel()
 	}
-	for id := range t.Users {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Users[id]), &results, Config)
+	all_results = append(all_results, t.RunPhase(teardownCtx, cx1client, logger, PHASE_TEARDOWN, Config)...)
+
+	if idle == "" {
+		hc.Result, hc.Reason = "PASS", failure
+		if failure != "" {
+			hc.Result = "FAIL"
+		}
+		if err := Config.runHooks(teardownCtx, logger, HOOK_AFTER_SET, t, hc); err != nil {
+			Config.state.SetFailed(t, err.Error())
+		}
 	}
-	for id := range t.AccessAssignments {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.AccessAssignments[id]), &results, Config)
+
+	return all_results
+}
+
+// the reason why the set did not pass, or an empty string if it passed
+func getFailure(results []TestResult) string {
+	for _, r := range results {
+		if r.Result == TST_FAIL {
+			return fmt.Sprintf("%v %v test (%v) failed: %v", r.CRUD, r.Module, r.TestObject, r.Reason)
+		} else if r.Result == TST_SKIP && r.Dependency {
+			return r.Reason
+		}
 	}
-	for id := range t.Queries {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Queries[id]), &results, Config)
+	return ""
+}
+
+// returns a skipped result for every test in the set, for when the set can not run at all
+func (t *TestSet) SkipTests(logger *logrus.Logger, reason string) []TestResult {
+	return t.endTests(logger, TST_SKIP, reason)
+}
+
+// returns a failed result for every test in the set, for when a hook preparing the tests failed
+func (t *TestSet) FailTests(logger *logrus.Logger, reason string) []TestResult {
+	return t.endTests(logger, TST_FAIL, reason)
+}
+
+func (t *TestSet) endTests(logger *logrus.Logger, status int, reason string) []TestResult {
+	results := []TestResult{}
+	for _, phase := range testPhases {
+		results = append(results, t.endPhase(logger, phase, status, reason)...)
 	}
-	for id := range t.Presets {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Presets[id]), &results, Config)
+	return results
+}
+
+func (t *TestSet) endPhase(logger *logrus.Logger, phase string, status int, reason string) []TestResult {
+	results := []TestResult{}
+	for _, CRUD := range []string{types.OP_CREATE, types.OP_READ, types.OP_UPDATE, types.OP_DELETE} {
+		for _, tests := range t.GetPhaseTests(phase) {
+			for _, test := range tests {
+				if test.IsType(CRUD) {
+					result := SkipResult(test, CRUD, t.Name, reason)
+					result.Result = status
+					result.Phase = phase
+					LogResult(logger, result)
+					results = append(results, result)
+				}
+			}
+		}
 	}
-	for id := range t.Scans {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Scans[id]), &results, Config)
+	return results
+}
+
+// runs the create, read, update and delete tests of the phase
+func (t *TestSet) RunPhase(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, phase string, Config *TestConfig) []TestResult {
+	results := []TestResult{}
+	for _, CRUD := range []string{types.OP_CREATE, types.OP_READ, types.OP_UPDATE, types.OP_DELETE} {
+		results = append(results, t.Run(ctx, cx1client, logger, phase, CRUD, Config)...)
 	}
-	for id := range t.Results {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Results[id]), &results, Config)
+	return results
+}
+
+func (t *TestSet) Run(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, phase, CRUD string, Config *TestConfig) []TestResult {
+	results := []TestResult{}
+
+	var pool *workerPool
+	if t.Parallel {
+		pool = Config.testPool
 	}
-	for id := range t.Reports {
-		RunTest(cx1client, logger, CRUD, t.Name, &(t.Reports[id]), &results, Config)
+
+	for _, tests := range t.GetPhaseTests(phase) {
+		test_results := make([][]TestResult, len(tests))
+		pool.Run(len(tests), func(id int) {
+			RunTest(ctx, cx1client, logger, phase, CRUD, t, tests[id], &test_results[id], Config)
+			for r := range test_results[id] {
+				test_results[id][r].Phase = phase
+			}
+			Config.state.finished(test_results[id])
+		})
+		for _, r := range test_results {
+			results = append(results, r...)
+		}
 	}
 
 	return results
 }
 
-func RunTest(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD, testName string, test TestRunner, results *[]TestResult, Config *TestConfig) {
+func RunTest(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, phase, CRUD string, set *TestSet, test TestRunner, results *[]TestResult, Config *TestConfig) {
+	testName := set.Name
 	if test.IsType(CRUD) {
 		var result TestResult
-		err := test.IsSupported(cx1client, logger, CRUD, &Config.Engines)
+		if ctx.Err() != nil {
+			result = SkipResult(test, CRUD, testName, getStopReason(ctx))
+			LogResult(logger, result)
+			*results = append(*results, result)
+			return
+		}
 
-		if err == nil && !CheckFlags(cx1client, logger, test) {
-			err = fmt.Errorf("test requires feature flag(s) %v to be enabled", strings.Join(test.GetFlags(), ","))
+		if (phase == PHASE_TEST && !Config.IsSelected(set, CRUD, test)) || (phase != PHASE_TEST && Config.IsFiltered() && !Config.HasSelectedTests(set)) {
+			result = SkipResult(test, CRUD, testName, "filtered")
+			LogResult(logger, result)
+			*results = append(*results, result)
+			return
 		}
 
-		if err != nil && !test.IsForced() {
-			result = MakeResult(test)
-			result.CRUD = CRUD
-			result.Name = testName
-			result.Duration = 0
-			result.Reason = err.Error()
-			result.Result = TST_SKIP
-			logger.Warnf("Test for %v %v is not supported and will be skipped. Reason: %s", CRUD, test.String(), err)
+		if result, ok := Config.state.checkpoint.GetResult(test, CRUD); ok {
+			logger.Debugf("Test %v %v was completed before the run was resumed", CRUD, test.String())
+			*results = append(*results, result)
+			return
+		}
+
+		if reason := Config.state.GetDependencyFailure(test.GetDependencies()); reason != "" {
+			result = SkipResult(test, CRUD, testName, reason)
+			result.Dependency = true
+			LogResult(logger, result)
+			*results = append(*results, result)
+			return
+		}
+
+		// variables are substituted in a copy of the test, the test keeps the references for its other operations and later runs
+		run := deepCopy(reflect.ValueOf(test)).Interface().(TestRunner)
+		if err := types.SubstituteVariables(run, Config.state.GetVariable); err != nil {
+			result = SkipResult(test, CRUD, testName, err.Error())
+			result.Dependency = true
+			LogResult(logger, result)
+			*results = append(*results, result)
+			return
+		}
+
+		err := run.IsSupported(ctx, cx1client, logger, CRUD, &Config.Engines)
+
+		if err == nil && !CheckFlags(cx1client, logger, run) {
+			err = fmt.Errorf("test requires feature flag(s) %v to be enabled", strings.Join(run.GetFlags(), ","))
+		}
+
+		if err != nil && !run.IsForced() {
+			result = SkipResult(run, CRUD, testName, err.Error())
+			logger.Warnf("Test for %v %v is not supported and will be skipped. Reason: %s", CRUD, run.String(), err)
 		} else {
-			result = Run(cx1client, logger, CRUD, testName, test, Config)
+			result = runWithHooks(ctx, cx1client, logger, phase, CRUD, set, run, Config)
+			types.RestoreTemplates(run, test)
+			reflect.ValueOf(test).Elem().Set(reflect.ValueOf(run).Elem())
+			if ctx.Err() == nil { // an interrupted test has to run again when resuming
+				Config.state.checkpoint.Save(logger, test, result, Config.state)
+			}
 		}
 
 		LogResult(logger, result)
@@ -150,53 +382,242 @@ func RunTest(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD, test
 	}
 }
 
-func Run(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD, testName string, test TestRunner, Config *TestConfig) TestResult {
+// the retry policy defined closest to the test applies
+func (c *TestConfig) GetRetryPolicy(set *TestSet, test TestRunner) *types.RetryPolicy {
+	if test.GetRetry() != nil {
+		return test.GetRetry()
+	}
+	if set.Retry != nil {
+		return set.Retry
+	}
+	return c.Retry
+}
+
+func Run(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, phase, CRUD, testName string, test TestRunner, retry *types.RetryPolicy, Config *TestConfig) TestResult {
 	//logger.Infof("Running test: %v %v", CRUD, test.String())
 	LogStart(logger, test, CRUD, testName)
+	Config.state.events.Emit(TestStarted{Set: testName, CRUD: CRUD, Module: test.GetModule(), TestObject: test.String()})
 	result := MakeResult(test)
 	result.CRUD = CRUD
 	result.Name = testName
 
-	err := test.Validate(CRUD)
-	if err != nil {
+	err := test.Validate(ctx, CRUD)
+	lookup := phase == PHASE_TEARDOWN && CRUD == types.OP_DELETE
+	if err != nil && !(lookup && errors.Is(err, types.ErrNotRead)) {
 		result.Result = TST_SKIP
 		result.Reason = err.Error()
 		return result
 	}
-	start := time.Now().UnixNano()
 
-	switch CRUD {
-	case types.OP_CREATE:
-		err = test.RunCreate(cx1client, logger, &Config.Engines)
-	case types.OP_READ:
-		err = test.RunRead(cx1client, logger, &Config.Engines)
-	case types.OP_UPDATE:
-		err = test.RunUpdate(cx1client, logger, &Config.Engines)
-	case types.OP_DELETE:
-		err = test.RunDelete(cx1client, logger, &Config.Engines)
+	// the teardown finds the objects itself, since the tests which created or read them may have failed
+	if lookup {
+		if err = runOperation(ctx, cx1client, logger, types.OP_READ, test, Config); err != nil {
+			result.Result = TST_SKIP
+			result.Reason = fmt.Sprintf("object to delete was not found: %s", err)
+			return result
+		}
+	}
+
+	attempts := 1
+	if !test.IsNegative() { // a negative test should not be retried until it passes
+		attempts = retry.GetAttempts()
+	}
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		start := time.Now().UnixNano()
+
+		err = runOperation(ctx, cx1client, logger, CRUD, test, Config)
+		if err == nil && CRUD == types.OP_READ {
+			err = types.CheckAssertions(test, test.GetExpectations())
+		}
+
+		duration := float64(time.Now().UnixNano()-start) / float64(time.Second)
+		result.Duration += duration
+		if attempts > 1 {
+			result.Attempts = append(result.Attempts, TestAttempt{Duration: duration})
+			if err != nil {
+				result.Attempts[attempt-1].Reason = err.Error()
+			}
+		}
+
+		if err == nil || attempt == attempts || !retry.Matches(err) || ctx.Err() != nil {
+			break
+		}
+
+		delay := retry.GetDelay(attempt)
+		logger.Warnf("Attempt %d/%d of %v %v test failed: %s - retrying in %v", attempt, attempts, CRUD, test.String(), err, delay)
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+	}
+
+	if err == nil {
+		Config.state.Capture(logger, test)
+	}
+
+	if _, ok := err.(timeoutError); ok { // a timeout is a failure, even for negative tests
+		result.Reason = err.Error()
+		result.Result = TST_FAIL
+		return result
 	}
 
-	duration := float64(time.Now().UnixNano()-start) / float64(time.Second)
-	result.Duration = duration
 	if err != nil {
 		result.Reason = err.Error()
 		if test.IsNegative() { // negative test with error = pass
 			result.Result = TST_PASS
-			return result
 		} else {
 			result.Result = TST_FAIL
-			return result
 		}
 	} else {
 		if test.IsNegative() { // negative test with no error = fail
 			result.Result = TST_FAIL
 			result.Reason = "action succeeded but should have failed"
-			return result
 		} else {
 			result.Result = TST_PASS
-			return result
 		}
 	}
+
+	// checked here rather than by the callers, so that every run of a test can be slow, including in load and soak mode
+	Config.checkDuration(CRUD, test, &result)
+	return result
+}
+
+// runs a single CRUD operation of the test, limited by the test's timeout
+func runOperation(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, test TestRunner, Config *TestConfig) error {
+	runCtx := ctx
+	if test.GetTimeout() > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, time.Duration(test.GetTimeout())*time.Second)
+		defer cancel()
+	}
+
+	// the operation runs on a copy of the test with a registry of its own, which are only applied once it returns,
+	// so that a test which is abandoned after being canceled can not change the test or the registry later on
+	run := copyTest(test)
+	created := &types.CleanupRegistry{}
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		switch CRUD {
+		case types.OP_CREATE:
+			err = run.RunCreate(runCtx, cx1client, logger, &Config.Engines, created)
+		case types.OP_READ:
+			err = run.RunRead(runCtx, cx1client, logger, &Config.Engines)
+		case types.OP_UPDATE:
+			err = run.RunUpdate(runCtx, cx1client, logger, &Config.Engines)
+		case types.OP_DELETE:
+			err = run.RunDelete(runCtx, cx1client, logger, &Config.Engines, created)
+		}
+		done <- err
+	}()
+	apply := func() {
+		reflect.ValueOf(test).Elem().Set(reflect.ValueOf(run).Elem())
+		Config.state.Created.Apply(created)
+	}
+
+	var err error
+	returned := true
+	select {
+	case err = <-done:
+		apply()
+		if err == nil || runCtx.Err() == nil {
+			return err
+		}
+	case <-runCtx.Done():
+		// give the test a chance to clean up, eg: cancel a running scan
+		gracePeriod := cancelGracePeriod
+		if waiter, ok := test.(CancelWaiter); ok {
+			gracePeriod += waiter.GetCancelTimeout()
+		}
+		select {
+		case err = <-done:
+			apply()
+		case <-time.After(gracePeriod):
+			logger.Warnf("%v %v test did not return within %v after being canceled and will be abandoned, objects it creates from now on are not deleted by automatic teardown", CRUD, test.String(), gracePeriod)
+			returned = false
+		}
+	}
+
+	// an abandoned test may still be using its state, so only tests which returned are canceled
+	if canceler, ok := test.(InFlightCanceler); ok && ctx.Err() != nil && Config.CancelOnInterrupt && returned {
+		if cancelErr := canceler.CancelInFlight(cx1client, logger); cancelErr != nil {
+			logger.Errorf("Failed to cancel the work of %v %v test: %s", CRUD, test.String(), cancelErr)
+		}
+	}
+
+	reason := getStopReason(ctx)
+	if ctx.Err() == nil {
+		reason = fmt.Sprintf("timeout: test did not finish within %d seconds", test.GetTimeout())
+	}
+	if err != nil {
+		reason = fmt.Sprintf("%v: %s", reason, err)
+	}
+	return timeoutError{reason}
+}
+
+// why a run stopped before all tests were executed
+func getStopReason(ctx context.Context) string {
+	if ctx.Err() == context.DeadlineExceeded {
+		return "timeout: run timeout exceeded"
+	}
+	return "interrupted"
+}
+
+// the test set name of the results of the automatic teardown
+const autoTeardownName = "Automatic teardown"
+
+// deletes all objects created during the run which were not deleted by a test, newest first
+func (c *TestConfig) RunAutoTeardown(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger) []TestResult {
+	c.state.teardownOnce.Do(func() {
+		objects := c.state.Created.GetObjects()
+		if len(objects) == 0 {
+			return
+		}
+
+		logger.Infof("Automatic teardown of %d remaining objects created during this run", len(objects))
+		for id := len(objects) - 1; id >= 0; id-- {
+			object := objects[id]
+			result := TestResult{
+				Result:       TST_PASS,
+				CRUD:         types.OP_DELETE,
+				Module:       object.Module,
+				Name:         autoTeardownName,
+				Id:           -1,
+				TestObject:   object.String(),
+				AutoTeardown: true,
+			}
+
+			start := time.Now().UnixNano()
+			err := c.state.Created.Teardown(object, func(object types.CreatedObject) error {
+				return teardownObject(cx1client, logger, object)
+			})
+			result.Duration = float64(time.Now().UnixNano()-start) / float64(time.Second)
+			if err != nil {
+				result.Result = TST_FAIL
+				result.Reason = err.Error()
+			}
+
+			LogResult(logger, result)
+			c.state.teardownResults = append(c.state.teardownResults, result)
+		}
+	})
+	return c.state.teardownResults
+}
+
+// a shallow copy of the test, Module.New ensures that tests are pointers to structs
+func copyTest(test TestRunner) TestRunner {
+	run := reflect.New(reflect.TypeOf(test).Elem())
+	run.Elem().Set(reflect.ValueOf(test).Elem())
+	return run.Interface().(TestRunner)
+}
+
+// deletes the object with the teardown of its module
+func teardownObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object types.CreatedObject) error {
+	if module, ok := GetModuleByName(object.Module); ok && module.Teardown != nil {
+		return module.Teardown(cx1client, logger, object)
+	}
+	return fmt.Errorf("deleting %v objects is not supported", object.Module)
 }
 
 func CheckFlags(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, test TestRunner) bool {
@@ -239,5 +660,11 @@ func LogResult(logger *logrus.Logger, result TestResult) {
 		logger.Warnf("Skip reason: %v", result.Reason)
 	case TST_PASS:
 		logger.Infof("PASS [%.3fs]: %v %v %v '%v' (%v)", result.Duration, result.CRUD, result.Module, testType, result.Name, result.TestObject)
+	case TST_SLOW:
+		logger.Warnf("SLOW [%.3fs]: %v %v %v '%v' (%v)", result.Duration, result.CRUD, result.Module, testType, result.Name, result.TestObject)
+		logger.Warnf("Slow reason: %v", result.Reason)
+	}
+	if len(result.Attempts) > 1 {
+		logger.Warnf("Test needed %d attempts", len(result.Attempts))
 	}
 }
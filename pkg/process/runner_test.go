@@ -0,0 +1,36 @@
+package process
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGetBatches(t *testing.T) {
+	set := func(name string, parallel bool, dependsOn ...string) TestSet {
+		return TestSet{Name: name, Parallel: parallel, DependsOn: dependsOn}
+	}
+
+	tests := []struct {
+		name        string
+		concurrency int
+		sets        []TestSet
+		batches     string
+	}{
+		{"sequential sets", 4, []TestSet{set("a", false), set("b", false)}, "[[0] [1]]"},
+		{"parallel sets are grouped", 4, []TestSet{set("a", true), set("b", true), set("c", true)}, "[[0 1 2]]"},
+		{"no grouping without concurrency", 1, []TestSet{set("a", true), set("b", true)}, "[[0] [1]]"},
+		{"a sequential set ends the batch", 4, []TestSet{set("a", true), set("b", true), set("c", false), set("d", true), set("e", true)}, "[[0 1] [2] [3 4]]"},
+		{"a sequential set is not added to a batch", 4, []TestSet{set("a", true), set("b", false), set("c", false)}, "[[0] [1] [2]]"},
+		{"dependency within the batch starts a new batch", 4, []TestSet{set("a", true), set("b", true), set("c", true, "a"), set("d", true)}, "[[0 1] [2 3]]"},
+		{"dependency on an earlier batch", 4, []TestSet{set("a", false), set("b", true, "a"), set("c", true, "a")}, "[[0] [1 2]]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := TestConfig{Concurrency: test.concurrency, Tests: test.sets}
+			if batches := fmt.Sprint(config.getBatches()); batches != test.batches {
+				t.Errorf("expected batches %v, got %v", test.batches, batches)
+			}
+		})
+	}
+}
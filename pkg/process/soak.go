@@ -0,0 +1,435 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/sirupsen/logrus"
+)
+
+// a test is reported as slower when its median duration in the last third of the iterations is this many times that of the first third
+const soakDriftFactor = 2.0
+
+// and at least this many seconds longer, so that very short tests do not vary too much
+const soakDriftMinimum = 0.5
+
+// an iteration which took less time is followed by a pause, so that a suite which fails straight away, eg: because the token expired,
+// does not run again and again until the deadline, a variable so that tests can shorten it
+var soakMinIteration = 30 * time.Second
+
+// the outcome of one run of the suite during a soak test, written as a line of the JSON lines file
+type SoakIteration struct {
+	Iteration      int
+	Suffix         string
+	Started        string
+	Elapsed        float64 // seconds
+	Total          Counter
+	PassRate       float64
+	MedianDuration float64          // median duration of the tests which ran, in seconds
+	Tests          []SoakTestResult `json:",omitempty"`
+
+	Stopped bool `json:",omitempty"` // the soak test ended during the iteration, so it is left out of the changes
+}
+
+type SoakTestResult struct {
+	Test     string // test set and test, without the suffix of the iteration
+	Result   string // PASS, SLOW, FAIL or SKIP
+	Duration float64
+	Reason   string `json:",omitempty"`
+}
+
+// a test which did not behave the same way throughout the soak test
+type SoakChange struct {
+	Test    string
+	Change  string // eg: started failing in iteration 7
+	Results string // result per iteration: P pass, L slow, F fail, S skip and - if the test did not run, eg: PPPLFFFF
+}
+
+type SoakReport struct {
+	Settings   ReportSettings `json:"Settings"`
+	Elapsed    float64
+	Iterations []SoakIteration
+	Changes    []SoakChange
+	Total      Counter
+	ResultFile string
+
+	QualityGate *QualityGateResult `json:",omitempty"` // evaluated over the tests of the iterations which completed
+}
+
+// runs the whole suite again and again until the duration has passed, each time loading the configuration with a fresh object name suffix
+// the results of every iteration are appended to resultFile as soon as it completes
+func RunSoak(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Config *TestConfig, options ConfigOptions, duration time.Duration, resultFile string, prepare func(*TestConfig) error) (*SoakReport, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("soak test needs a duration")
+	}
+	if err := ValidateLoadReportType(Config.ReportType); err != nil {
+		return nil, err
+	}
+	if Config.StepSummary {
+		logger.Warnf("StepSummary is not supported by soak tests, the step summary will not be written")
+	}
+	if !Config.usesRunSuffix {
+		logger.Warnf("The configuration does not use %%E2E_RUN_SUFFIX%% in its object names, every iteration will use the same objects")
+	}
+
+	results, err := os.OpenFile(resultFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open soak test results file %v: %s", resultFile, err)
+	}
+	defer results.Close()
+
+	Config.startRun(nil)
+	if err := Config.runHooks(ctx, logger, HOOK_BEFORE_ALL, nil, hookContext{}); err != nil {
+		return nil, fmt.Errorf("the soak test will not run: %s", err)
+	}
+
+	report := SoakReport{ResultFile: resultFile}
+	baseSuffix := os.Getenv("E2E_RUN_SUFFIX")
+	start := time.Now()
+	deadline := start.Add(duration)
+	var soakErr error
+	var gateResults []TestResult
+
+	// the iteration which is running when the duration has passed is stopped like an interrupted run
+	soakCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	logger.Infof("Starting soak test until %v", deadline.Format("2006-01-02 15:04:05"))
+	for id := 1; soakCtx.Err() == nil; id++ {
+		iterationStart := time.Now()
+		iteration := SoakIteration{
+			Iteration: id,
+			Suffix:    fmt.Sprintf("%v-i%d-%v", baseSuffix, id, strconv.FormatInt(iterationStart.Unix(), 36)),
+			Started:   iterationStart.String(),
+		}
+
+		logger.Infof("Starting soak test iteration %d with suffix %v", id, iteration.Suffix)
+		testResults, err := runIteration(soakCtx, cx1client, logger, Config.ConfigPath, ConfigOptions{Lenient: options.Lenient, RunSuffix: iteration.Suffix}, prepare)
+		if err != nil {
+			soakErr = fmt.Errorf("iteration %d failed to start: %s", id, err)
+			break
+		}
+		iteration.Elapsed = time.Since(iterationStart).Seconds()
+		iteration.Stopped = soakCtx.Err() != nil
+		iteration.addResults(testResults)
+		if !iteration.Stopped { // like the totals, the gate only covers the iterations which completed
+			gateResults = append(gateResults, withoutAutoTeardown(testResults)...)
+		}
+
+		line, err := json.Marshal(iteration)
+		if err == nil {
+			_, err = results.Write(append(line, '\n'))
+		}
+		if err != nil {
+			logger.Errorf("Failed to write iteration %d to %v: %s", id, resultFile, err)
+		}
+
+		logger.Infof("Soak test iteration %d: PASSED %d, SLOW %d, FAILED %d, SKIPPED %d in %.1fs", id, iteration.Total.Pass, iteration.Total.Slow, iteration.Total.Fail, iteration.Total.Skip, iteration.Elapsed)
+		report.Iterations = append(report.Iterations, iteration)
+
+		if iteration.Stopped {
+			break
+		}
+		if iteration.Total.Pass+iteration.Total.Slow+iteration.Total.Fail == 0 {
+			soakErr = fmt.Errorf("iteration %d ran no tests, the soak test is stopped", id)
+			break
+		}
+		if wait := soakMinIteration - time.Since(iterationStart); wait > 0 {
+			logger.Infof("Soak test iteration %d took less than %v, waiting %v before the next iteration", id, soakMinIteration, wait.Round(time.Millisecond))
+			select {
+			case <-soakCtx.Done():
+			case <-time.After(wait):
+			}
+		}
+	}
+	report.Elapsed = time.Since(start).Seconds()
+	if ctx.Err() != nil {
+		Config.state.abortReason = getStopReason(ctx)
+	}
+
+	hc := hookContext{Result: "PASS"}
+	for _, iteration := range report.Iterations {
+		if iteration.Total.Fail > 0 {
+			hc.Result = "FAIL"
+			break
+		}
+	}
+	_ = Config.runHooks(context.Background(), logger, HOOK_AFTER_ALL, nil, hc) // also after an interrupted run, like the teardown
+
+	report.finish(Config)
+	report.QualityGate = Config.EvaluateQualityGate(gateResults)
+	if err := GenerateSoakReport(&report, logger, Config); err != nil && soakErr == nil {
+		soakErr = err
+	}
+	return &report, soakErr
+}
+
+func (i *SoakIteration) addResults(results []TestResult) {
+	durations := []float64{}
+	for id := range results {
+		r := &results[id]
+		i.Total.AddTest(r)
+		details := makeTestDetails(r)
+
+		result := SoakTestResult{
+			Test:     strings.ReplaceAll(fmt.Sprintf("%v - %v", details.Name, details.Test), i.Suffix, ""),
+			Result:   resultName(r.Result),
+			Duration: r.Duration,
+		}
+		if r.Result != TST_PASS {
+			result.Reason = r.Reason
+		}
+		if r.Result != TST_SKIP {
+			durations = append(durations, r.Duration)
+		}
+		i.Tests = append(i.Tests, result)
+	}
+
+	ran := i.Total.Pass + i.Total.Slow + i.Total.Fail + i.Total.Skip
+	if ran > 0 {
+		i.PassRate = float64(i.Total.Pass+i.Total.Slow) / float64(ran)
+	}
+	i.MedianDuration = median(durations)
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// fills in the totals and finds the tests whose results or durations changed between iterations
+func (r *SoakReport) finish(Config *TestConfig) {
+	r.Settings = Config.getReportSettings()
+
+	// tests in the order in which they first ran, with their result and duration in each iteration
+	order := []string{}
+	history := make(map[string][]*SoakTestResult)
+	for id := range r.Iterations {
+		iteration := &r.Iterations[id]
+		if iteration.Stopped { // its remaining tests were skipped because the time ran out, not because of a problem
+			continue
+		}
+		r.Total.Pass += iteration.Total.Pass
+		r.Total.Slow += iteration.Total.Slow
+		r.Total.Fail += iteration.Total.Fail
+		r.Total.Skip += iteration.Total.Skip
+		for tid := range iteration.Tests {
+			test := &iteration.Tests[tid]
+			if _, ok := history[test.Test]; !ok {
+				order = append(order, test.Test)
+				history[test.Test] = make([]*SoakTestResult, len(r.Iterations))
+			}
+			history[test.Test][id] = test
+		}
+	}
+
+	for _, test := range order {
+		if change, ok := getSoakChange(test, history[test]); ok {
+			r.Changes = append(r.Changes, change)
+		}
+	}
+
+	// the JSON lines file has the details, the report only the summary of each iteration
+	for id := range r.Iterations {
+		r.Iterations[id].Tests = nil
+	}
+}
+
+var soakResultLetters = map[string]string{"PASS": "P", "SLOW": "L", "FAIL": "F", "SKIP": "S"}
+
+func getSoakChange(test string, results []*SoakTestResult) (SoakChange, bool) {
+	change := SoakChange{Test: test}
+	first, last := "", ""
+	failed, ran, switches, switchedAt := 0, 0, 0, 0
+	for id, result := range results {
+		if result == nil {
+			change.Results += "-"
+			continue
+		}
+		change.Results += soakResultLetters[result.Result]
+		if result.Result == "SKIP" {
+			continue
+		}
+		ran++
+		outcome := "pass"
+		if result.Result == "FAIL" {
+			outcome = "fail"
+			failed++
+		}
+		if first == "" {
+			first = outcome
+		} else if outcome != last {
+			switches++
+			switchedAt = id
+		}
+		last = outcome
+	}
+
+	switch {
+	case switches == 1 && first == "pass":
+		change.Change = fmt.Sprintf("started failing in iteration %d", switchedAt+1)
+	case switches == 1 && first == "fail":
+		change.Change = fmt.Sprintf("recovered in iteration %d", switchedAt+1)
+	case switches > 1:
+		change.Change = fmt.Sprintf("failed in %d of %d iterations", failed, ran)
+	}
+	if change.Change != "" {
+		return change, true
+	}
+
+	// durations of the runs which passed, early against late
+	durations := []float64{}
+	for _, result := range results {
+		if result != nil && (result.Result == "PASS" || result.Result == "SLOW") {
+			durations = append(durations, result.Duration)
+		}
+	}
+	if len(durations) < 3 {
+		return change, false
+	}
+	third := len(durations) / 3
+	early, late := median(durations[:third]), median(durations[len(durations)-third:])
+	if late > early*soakDriftFactor && late-early >= soakDriftMinimum {
+		change.Change = fmt.Sprintf("median duration went from %.2fs to %.2fs", early, late)
+		return change, true
+	}
+	return change, false
+}
+
+// the share of the tests which passed across all iterations, slow tests count as passed unless slowFails is set
+func (r *SoakReport) GetStatus(slowFails bool) float32 {
+	summary := ReportSummary{Total: r.Total}
+	return summary.GetStatus(slowFails)
+}
+
+// writes the soak test report to the console and to the reports configured in Config.ReportType
+func GenerateSoakReport(report *SoakReport, logger *logrus.Logger, Config *TestConfig) error {
+	var reportErr error
+
+	fmt.Println("")
+	fmt.Printf("Soak test: %d iterations in %.1fs, results of every test in %v\n", len(report.Iterations), report.Elapsed, report.ResultFile)
+	for _, i := range report.Iterations {
+		stopped := ""
+		if i.Stopped {
+			stopped = " (stopped)"
+		}
+		fmt.Printf("Iteration %d%v: pass rate %.1f%%, median duration %.3fs, FAILED %d\n", i.Iteration, stopped, i.PassRate*100, i.MedianDuration, i.Total.Fail)
+	}
+	if report.Settings.Aborted {
+		fmt.Printf("ABORTED: %v, tests which did not run were skipped\n", report.Settings.AbortReason)
+	}
+	if throttling := report.Settings.throttling(); throttling != "" {
+		fmt.Printf("Throttled: %v\n", throttling)
+	}
+	if len(report.Changes) > 0 {
+		fmt.Println("")
+		fmt.Println("Tests whose behaviour changed:")
+		for _, c := range report.Changes {
+			fmt.Printf("%v: %v [%v]\n", c.Test, c.Change, c.Results)
+		}
+	}
+	if report.QualityGate != nil {
+		outputQualityGateConsole(report.QualityGate)
+	}
+
+	if Config.HasReportType(REPORT_HTML) {
+		if err := OutputSoakReportHTML(fmt.Sprintf("%v.html", Config.ReportName), report); err != nil {
+			logger.Errorf("Failed to write HTML report to %v.html: %s", Config.ReportName, err)
+			reportErr = fmt.Errorf("failed to write HTML report to %v.html: %s", Config.ReportName, err)
+		}
+	}
+	if Config.HasReportType(REPORT_JSON) {
+		if err := OutputSoakReportJSON(fmt.Sprintf("%v.json", Config.ReportName), report); err != nil {
+			logger.Errorf("Failed to write JSON report to %v.json: %s", Config.ReportName, err)
+			if reportErr == nil {
+				reportErr = fmt.Errorf("failed to write JSON report to %v.json: %s", Config.ReportName, err)
+			}
+		}
+	}
+	return reportErr
+}
+
+func OutputSoakReportJSON(reportName string, reportData *SoakReport) error {
+	data, err := json.Marshal(*reportData)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(reportName, data, 0644)
+}
+
+func OutputSoakReportHTML(reportName string, reportData *SoakReport) error {
+	report, err := os.Create(reportName)
+	if err != nil {
+		return err
+	}
+	defer report.Close()
+
+	report.WriteString(fmt.Sprintf("<html><head><title>%v soak test - %v</title></head><body>", reportData.Settings.Target, reportData.Settings.Timestamp))
+	report.WriteString("<h2>Settings</h2>")
+	report.WriteString(fmt.Sprintf("Running soak test against %v<br>", reportData.Settings.Target))
+	report.WriteString(fmt.Sprintf("Target versions are: %v<br>", reportData.Settings.Version.String()))
+	report.WriteString(fmt.Sprintf("Authenticated using %v<br>", reportData.Settings.Auth))
+	report.WriteString(fmt.Sprintf("Test set defined in configuration %v<br>", reportData.Settings.Config))
+	report.WriteString(fmt.Sprintf("Execution timestamp: %v.<br>", reportData.Settings.Timestamp))
+	report.WriteString(fmt.Sprintf("%d iterations in %.1f seconds, the results of every test are in %v.<br>", len(reportData.Iterations), reportData.Elapsed, reportData.ResultFile))
+	if reportData.Settings.Aborted {
+		report.WriteString(fmt.Sprintf("<span style='color:red'>Aborted: %v, tests which did not run were skipped.</span><br>", reportData.Settings.AbortReason))
+	}
+	if throttling := reportData.Settings.throttling(); throttling != "" {
+		report.WriteString(fmt.Sprintf("Throttled: %v.<br>", throttling))
+	}
+
+	if reportData.QualityGate != nil {
+		report.WriteString("<h2>Quality gate</h2>")
+		writeQualityGate(report, reportData.QualityGate)
+	}
+
+	report.WriteString("<h2>Changed behaviour</h2>")
+	if len(reportData.Changes) == 0 {
+		report.WriteString("<p>All tests behaved the same way in every iteration.</p>")
+	} else {
+		report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th>Test</th><th>Change</th><th>Results per iteration</th></tr>\n")
+		for _, c := range reportData.Changes {
+			report.WriteString(fmt.Sprintf("<tr><td>%v</td><td><span style='color:red'>%v</span></td><td><code>%v</code></td></tr>\n", html.EscapeString(c.Test), c.Change, c.Results))
+		}
+		report.WriteString("</table>\n")
+	}
+
+	report.WriteString("<h2>Iterations</h2>")
+	report.WriteString("<table border=1 style='border:1px solid black' cellpadding=2 cellspacing=0><tr><th>Iteration</th><th>Started</th><th>Duration (sec)</th><th>Pass</th><th>Slow</th><th>Fail</th><th>Skip</th><th>Pass rate</th><th>Median test duration (sec)</th></tr>\n")
+	for _, i := range reportData.Iterations {
+		iteration := fmt.Sprintf("%d", i.Iteration)
+		if i.Stopped {
+			iteration += " (stopped)"
+		}
+		report.WriteString(fmt.Sprintf("<tr><td>%v</td><td>%v</td><td>%.1f</td>", iteration, i.Started, i.Elapsed))
+		writeCell(report, i.Total.Pass, true)
+		writeColorCell(report, i.Total.Slow, "darkorange")
+		writeCell(report, i.Total.Fail, false)
+		writeColorCell(report, i.Total.Skip, "orange")
+		report.WriteString(fmt.Sprintf("<td>%.1f%%</td><td>%.3f</td></tr>\n", i.PassRate*100, i.MedianDuration))
+	}
+	report.WriteString("</table>\n")
+
+	_, err = report.WriteString("</body></html>")
+	if err != nil {
+		return err
+	}
+	return report.Sync()
+}
@@ -0,0 +1,153 @@
+package process
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+)
+
+func TestGetSoakChange(t *testing.T) {
+	// eg: "PPF-S" with a duration of 1 second for each test which ran
+	history := func(results string, durations ...float64) []*SoakTestResult {
+		names := map[rune]string{'P': "PASS", 'L': "SLOW", 'F': "FAIL", 'S': "SKIP"}
+		history := make([]*SoakTestResult, len(results))
+		for id, r := range results {
+			if r == '-' {
+				continue
+			}
+			duration := 1.0
+			if id < len(durations) {
+				duration = durations[id]
+			}
+			history[id] = &SoakTestResult{Result: names[r], Duration: duration}
+		}
+		return history
+	}
+
+	tests := []struct {
+		name    string
+		results []*SoakTestResult
+		changed bool
+		change  string
+		letters string
+	}{
+		{"always passed", history("PPPPPP"), false, "", "PPPPPP"},
+		{"always failed", history("FFFF"), false, "", "FFFF"},
+		{"started failing", history("PPPFFF"), true, "started failing in iteration 4", "PPPFFF"},
+		{"recovered", history("FFPPPP"), true, "recovered in iteration 3", "FFPPPP"},
+		{"flaky", history("PFPPFP"), true, "failed in 2 of 6 iterations", "PFPPFP"},
+		{"slow counts as passed", history("PLPLPL"), false, "", "PLPLPL"},
+		{"skips and missing runs are ignored", history("PS-PFF"), true, "started failing in iteration 5", "PS-PFF"},
+		{"slower over time", history("PPPPPP", 1, 1, 1, 2, 3, 3), true, "median duration went from 1.00s to 3.00s", "PPPPPP"},
+		{"small increase of a short test", history("PPPPPP", 0.1, 0.1, 0.1, 0.3, 0.3, 0.3), false, "", "PPPPPP"},
+		{"too few runs to compare durations", history("PP", 1, 10), false, "", "PP"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			change, changed := getSoakChange("set - test", test.results)
+			if changed != test.changed || change.Change != test.change {
+				t.Errorf("expected %v %q, got %v %q", test.changed, test.change, changed, change.Change)
+			}
+			if change.Results != test.letters {
+				t.Errorf("expected results %v, got %v", test.letters, change.Results)
+			}
+		})
+	}
+}
+
+func TestSoakStoppedIteration(t *testing.T) {
+	report := SoakReport{Iterations: []SoakIteration{
+		{Iteration: 1, Tests: []SoakTestResult{{Test: "a", Result: "PASS"}}},
+		{Iteration: 2, Tests: []SoakTestResult{{Test: "a", Result: "PASS"}}},
+		{Iteration: 3, Tests: []SoakTestResult{{Test: "a", Result: "FAIL"}}, Total: Counter{Fail: 1}, Stopped: true},
+	}}
+	report.finish(&TestConfig{})
+	if len(report.Changes) != 0 {
+		t.Errorf("expected the stopped iteration to be ignored, got %v", report.Changes)
+	}
+	if report.Total != (Counter{}) {
+		t.Errorf("expected the stopped iteration to not be counted, got %+v", report.Total)
+	}
+}
+
+// the pause after short iterations is shortened for the duration of the test
+func setSoakMinIteration(t *testing.T, duration time.Duration) {
+	previous := soakMinIteration
+	soakMinIteration = duration
+	t.Cleanup(func() { soakMinIteration = previous })
+}
+
+func TestRunSoak(t *testing.T) {
+	setSoakMinIteration(t, 0)
+	config := loadTestConfig(t, `QualityGate:
+  SkipsFail: true
+  Rules: [ { MaxFail: 0 } ]
+Tests:
+  - Name: soak
+    Fakes:
+      - Name: fake%E2E_RUN_SUFFIX%
+        Test: CRD
+        Sleep: 0.05
+`)
+	resultFile := filepath.Join(t.TempDir(), "soak.jsonl")
+
+	report, err := RunSoak(context.Background(), &Cx1ClientGo.Cx1Client{}, newTestLogger(), config, ConfigOptions{}, 500*time.Millisecond, resultFile, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	last := report.Iterations[len(report.Iterations)-1]
+	if len(report.Iterations) < 2 || !last.Stopped {
+		t.Fatalf("expected completed iterations followed by one which was stopped at the deadline, got %+v", report.Iterations)
+	}
+	completed := uint(3 * (len(report.Iterations) - 1))
+	if report.Total != (Counter{Pass: completed}) {
+		t.Errorf("expected %d passed tests of the completed iterations, got %+v", completed, report.Total)
+	}
+	if status := report.GetStatus(false); status != 1 || report.Settings.Aborted {
+		t.Errorf("expected a soak test which ran until its deadline to pass, got status %v, aborted %v", status, report.Settings.Aborted)
+	}
+	if report.QualityGate == nil || !report.QualityGate.Passed {
+		t.Errorf("expected the quality gate to pass without the stopped iteration, got %+v", report.QualityGate)
+	}
+}
+
+func TestRunSoakShortIterations(t *testing.T) {
+	setSoakMinIteration(t, 100*time.Millisecond)
+
+	tests := []struct {
+		name          string
+		fake          string
+		minIterations int
+		maxIterations int
+		err           string
+	}{
+		// without the pause, iterations which take no time would run hundreds of times
+		{"short iterations are followed by a pause", "{ Name: fake, Test: C }", 2, 6, ""},
+		{"iterations which run no tests stop the soak test", "{ Test: C }", 1, 1, "iteration 1 ran no tests, the soak test is stopped"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := loadTestConfig(t, "Tests:\n  - Name: soak\n    Fakes: [ "+test.fake+" ]\n")
+			resultFile := filepath.Join(t.TempDir(), "soak.jsonl")
+
+			report, err := RunSoak(context.Background(), &Cx1ClientGo.Cx1Client{}, newTestLogger(), config, ConfigOptions{}, 500*time.Millisecond, resultFile, nil)
+			if test.err == "" && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			} else if test.err != "" && (err == nil || err.Error() != test.err) {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+			if report == nil {
+				t.Fatalf("expected a report")
+			}
+			if len(report.Iterations) < test.minIterations || len(report.Iterations) > test.maxIterations {
+				t.Errorf("expected %d to %d iterations, got %d", test.minIterations, test.maxIterations, len(report.Iterations))
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package process
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// runState holds the bookkeeping shared between the tests of a single run
+type runState struct {
+	lock       sync.Mutex
+	failedSets map[string]string // test set name -> failure which caused it to not pass
+
+	Created         *types.CleanupRegistry
+	teardownOnce    sync.Once
+	teardownResults []TestResult
+
+	variables  map[string]string // values captured by tests, referenced as ${name}
+	checkpoint *checkpointWriter
+	events     *eventEmitter
+
+	hookResults []HookResult
+
+	abortReason string // why the run stopped before all tests had run, empty if it completed
+}
+
+func newRunState() *runState {
+	return &runState{
+		failedSets: make(map[string]string),
+		Created:    &types.CleanupRegistry{},
+		variables:  make(map[string]string),
+	}
+}
+
+func (s *runState) SetVariable(name, value string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.variables[name] = value
+}
+
+func (s *runState) GetVariable(name string) (string, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	value, ok := s.variables[name]
+	return value, ok
+}
+
+func (s *runState) GetVariables() map[string]string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	variables := make(map[string]string, len(s.variables))
+	for name, value := range s.variables {
+		variables[name] = value
+	}
+	return variables
+}
+
+// stores the values listed in the test's Capture block, values which are not available are logged and skipped
+func (s *runState) Capture(logger *logrus.Logger, test TestRunner) {
+	for name, path := range test.GetCaptures() {
+		value, err := types.ResolveCapturePath(test, path)
+		if err != nil {
+			logger.Warnf("Failed to capture %v from %v %v: %s", name, test.GetModule(), test.String(), err)
+			continue
+		}
+		logger.Debugf("Captured %v = %v from %v %v", name, value, test.GetModule(), test.String())
+		s.SetVariable(name, value)
+	}
+}
+
+func (s *runState) AddHookResult(result HookResult) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.hookResults = append(s.hookResults, result)
+}
+
+func (s *runState) GetHookResults() []HookResult {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]HookResult{}, s.hookResults...)
+}
+
+// emits a TestFinished event for each result
+func (s *runState) finished(results []TestResult) []TestResult {
+	for _, result := range results {
+		s.events.Emit(TestFinished{Result: result})
+	}
+	return results
+}
+
+func (s *runState) SetFailed(set *TestSet, reason string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.failedSets[set.Name]; !ok {
+		s.failedSets[set.Name] = reason
+	}
+	if _, ok := s.failedSets[set.BaseName]; !ok && set.BaseName != "" { // a matrix did not pass if any of its instances did not pass
+		s.failedSets[set.BaseName] = fmt.Sprintf("%v: %v", set.Name, reason)
+	}
+}
+
+// returns the reason why the first failed dependency did not pass, or an empty string if all dependencies passed
+func (s *runState) GetDependencyFailure(dependencies []string) string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, dep := range dependencies {
+		if reason, ok := s.failedSets[dep]; ok {
+			return fmt.Sprintf("depends on test set '%v' which did not pass: %v", dep, reason)
+		}
+	}
+	return ""
+}
@@ -1,28 +1,28 @@
 package process
 
 import (
+	"time"
+
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/cxpsemea/cx1e2e/pkg/types"
 )
 
 type TestSet struct {
-	Name              string                       `yaml:"Name"`
-	File              string                       `yaml:"File"`
-	AccessAssignments []types.AccessAssignmentCRUD `yaml:"AccessAssignments"`
-	Applications      []types.ApplicationCRUD      `yaml:"Applications"`
-	Flags             []types.FlagCRUD             `yaml:"Flags"`
-	Groups            []types.GroupCRUD            `yaml:"Groups"`
-	Imports           []types.ImportCRUD           `yaml:"Imports"`
-	Presets           []types.PresetCRUD           `yaml:"Presets"`
-	Projects          []types.ProjectCRUD          `yaml:"Projects"`
-	Queries           []types.CxQLCRUD             `yaml:"Queries"`
-	Reports           []types.ReportCRUD           `yaml:"Reports"`
-	Results           []types.ResultCRUD           `yaml:"Results"`
-	Roles             []types.RoleCRUD             `yaml:"Roles"`
-	Scans             []types.ScanCRUD             `yaml:"Scans"`
-	Users             []types.UserCRUD             `yaml:"Users"`
-
-	Wait uint `yaml:"Wait"`
+	Name    string                  `yaml:"Name"`
+	File    string                  `yaml:"File"`
+	Modules map[string][]TestRunner `yaml:"-"` // module key -> tests, decoded from the keys of the registered modules
+
+	Wait      uint                `yaml:"Wait"`
+	Parallel  bool                `yaml:"Parallel"`  // this set and its tests are independent and may run concurrently
+	DependsOn []string            `yaml:"DependsOn"` // names of test sets which must pass before this set can run
+	Retry     *types.RetryPolicy  `yaml:"Retry"`     // overrides the retry policy of the config
+	Tags      []string            `yaml:"Tags"`      // free-form tags used to select tests
+	Matrix    map[string][]string `yaml:"Matrix"`    // the set is repeated for each combination of values, available as ${key}
+	Hooks     *Hooks              `yaml:"Hooks"`     // run in addition to the hooks of the config
+	Setup     *TestSection        `yaml:"Setup"`     // run before the tests, which are skipped if the setup fails
+	Teardown  *TestSection        `yaml:"Teardown"`  // run after the tests even if they failed, deleted objects are looked up first
+
+	BaseName string `yaml:"-"` // name of the set before matrix expansion
 }
 
 type TestConfig struct {
@@ -37,21 +37,63 @@ type TestConfig struct {
 	AuthUser           string                  `yaml:"-"`
 	ReportType         string                  `yaml:"ReportType"`
 	ReportName         string                  `yaml:"ReportName"`
+	Concurrency        int                     `yaml:"Concurrency"`
+	Retry              *types.RetryPolicy      `yaml:"Retry"`
+	AutoTeardown       bool                    `yaml:"AutoTeardown"` // delete objects created during the run which were not deleted by a test
+	Hooks              *Hooks                  `yaml:"Hooks"`
 	Engines            types.EnabledEngines    `yaml:"-"`
 	EnvironmentVersion Cx1ClientGo.VersionInfo `yaml:"-"`
+
+	MaxDuration  map[string]DurationBudget `yaml:"MaxDuration"`  // module name -> default maximum seconds per operation
+	StrictTiming bool                      `yaml:"StrictTiming"` // a test which exceeds its MaxDuration fails instead of being slow
+	FailOnSlow   bool                      `yaml:"FailOnSlow"`   // slow tests count as failed for the exit code
+
+	QualityGate *QualityGate `yaml:"QualityGate"` // rules which decide whether the run passed, instead of requiring all tests to pass
+
+	Environments         []Environment `yaml:"Environments"`         // run the whole configuration against each of these tenants
+	ParallelEnvironments bool          `yaml:"ParallelEnvironments"` // run against all environments at the same time
+	Environment          string        `yaml:"-"`                    // name of the environment of the current run
+
+	RateLimit   RateLimits   `yaml:"RateLimit"` // limits the requests to the Cx1 and IAM hosts
+	RateLimiter *RateLimiter `yaml:"-"`         // transport of the client used for the run, to report the time spent throttled
+
+	CheckpointPath string      `yaml:"-"` // written after every test, empty to disable
+	Resume         *Checkpoint `yaml:"-"` // checkpoint of the run which is being resumed
+
+	HandleInterrupts  bool `yaml:"-"`                 // stop the run on SIGINT or SIGTERM, set when running from the command-line
+	CancelOnInterrupt bool `yaml:"CancelOnInterrupt"` // cancel the scans of tests which were aborted because the run stopped
+
+	StepSummary bool `yaml:"StepSummary"` // append the markdown report to the file named by GITHUB_STEP_SUMMARY
+
+	Include  []TestSelector `yaml:"-"`
+	Exclude  []TestSelector `yaml:"-"`
+	testPool *workerPool
+	state    *runState
+
+	usesRunSuffix bool // the configuration or one of its sub-tests contains %E2E_RUN_SUFFIX%
 }
 
 type TestResult struct {
-	FailTest   bool
-	Result     int
-	CRUD       string
-	Module     string
-	Duration   float64
-	Name       string
-	Id         int
-	TestObject string
-	Reason     string
-	TestSource string
+	FailTest     bool
+	Result       int
+	CRUD         string
+	Module       string
+	Duration     float64
+	Name         string
+	Id           int
+	TestObject   string
+	Reason       string
+	TestSource   string
+	Dependency   bool     // skipped because a dependency did not pass
+	AutoTeardown bool     // deleted an object left behind by the run, in the test set named autoTeardownName
+	Phase        string   // Setup, Teardown or empty for the tests of the set itself
+	Tags         []string // of the test, without those of its test set
+	Attempts     []TestAttempt
+}
+
+type TestAttempt struct {
+	Duration float64
+	Reason   string
 }
 
 // test result output
@@ -59,6 +101,7 @@ type Counter struct {
 	Pass uint
 	Fail uint
 	Skip uint
+	Slow uint // passed, but took longer than their MaxDuration
 }
 type CounterSet struct {
 	Create Counter
@@ -68,31 +111,27 @@ type CounterSet struct {
 }
 
 type ReportSettings struct {
-	Target    string                  `json:"TestTarget"`
-	Auth      string                  `json:"Authentication"`
-	Config    string                  `json:"TestConfig"`
-	Timestamp string                  `json:"ExecutionTime"`
-	E2ESuffix string                  `json:"E2ESuffix"`
-	Version   Cx1ClientGo.VersionInfo `json:"TargetVersions"`
+	Target      string                  `json:"TestTarget"`
+	Auth        string                  `json:"Authentication"`
+	Config      string                  `json:"TestConfig"`
+	Timestamp   string                  `json:"ExecutionTime"`
+	E2ESuffix   string                  `json:"E2ESuffix"`
+	Resumed     string                  `json:"ResumedFrom,omitempty"`
+	Env         string                  `json:"Environment,omitempty"`
+	Throttled   float64                 `json:"ThrottledSeconds,omitempty"` // time requests spent waiting for the rate limit
+	Rejected    int                     `json:"TooManyRequests,omitempty"`  // requests rejected with 429 Too Many Requests
+	Aborted     bool                    `json:"Aborted"`                    // the run was interrupted or timed out, tests which did not run are skipped
+	AbortReason string                  `json:"AbortReason,omitempty"`
+	Version     Cx1ClientGo.VersionInfo `json:"TargetVersions"`
+
+	started time.Time // the same time as Timestamp, for reports which need it in another format
 }
 
 type ReportSummary struct {
-	Total Counter `json:"Total"`
-	Area  struct {
-		Access      CounterSet
-		Application CounterSet
-		Flag        CounterSet
-		Group       CounterSet
-		Import      CounterSet
-		Preset      CounterSet
-		Project     CounterSet
-		Query       CounterSet
-		Result      CounterSet
-		Report      CounterSet
-		Role        CounterSet
-		Scan        CounterSet
-		User        CounterSet
-	} `json:"Area"`
+	Total Counter                `json:"Total"`
+	Area  map[string]*CounterSet `json:"Area"` // module area -> results
+
+	Teardown Counter `json:"Teardown"` // not included in Total
 }
 
 type ReportTestDetails struct {
@@ -100,12 +139,23 @@ type ReportTestDetails struct {
 	Source     string
 	Test       string
 	Duration   float64
-	ResultType int `json:"-"`
+	ResultType int    `json:"-"`
+	Phase      string `json:",omitempty"`
 	Result     string
+	Attempts   []TestAttempt `json:",omitempty"`
+
+	Module string `json:",omitempty"`
+	CRUD   string `json:",omitempty"`
+	Reason string `json:"-"` // why the test did not pass, also part of Result
 }
 
 type Report struct {
-	Settings ReportSettings      `json:"Settings"`
-	Summary  ReportSummary       `json:"Summary"`
-	Details  []ReportTestDetails `json:"Details"`
+	Settings     ReportSettings      `json:"Settings"`
+	Summary      ReportSummary       `json:"Summary"`
+	Details      []ReportTestDetails `json:"Details"`
+	Teardown     []ReportTestDetails `json:"Teardown,omitempty"`
+	AutoTeardown []ReportTestDetails `json:"AutoTeardown,omitempty"`
+	Variables    map[string]string   `json:"Variables,omitempty"`
+	Hooks        []HookResult        `json:"Hooks,omitempty"`
+	QualityGate  *QualityGateResult  `json:"QualityGate,omitempty"`
 }
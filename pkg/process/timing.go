@@ -0,0 +1,73 @@
+package process
+
+import (
+	"fmt"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+)
+
+// the longest each operation of a module may take in seconds, 0 for no limit
+type DurationBudget struct {
+	Create float64 `yaml:"Create"`
+	Read   float64 `yaml:"Read"`
+	Update float64 `yaml:"Update"`
+	Delete float64 `yaml:"Delete"`
+}
+
+func (b DurationBudget) get(CRUD string) float64 {
+	switch CRUD {
+	case types.OP_CREATE:
+		return b.Create
+	case types.OP_READ:
+		return b.Read
+	case types.OP_UPDATE:
+		return b.Update
+	case types.OP_DELETE:
+		return b.Delete
+	}
+	return 0
+}
+
+func (c *TestConfig) validateDurations() error {
+	for module, budget := range c.MaxDuration {
+		if _, ok := GetModuleByName(module); !ok {
+			return fmt.Errorf("MaxDuration is defined for unknown module '%v'", module)
+		}
+		if budget.Create < 0 || budget.Read < 0 || budget.Update < 0 || budget.Delete < 0 {
+			return fmt.Errorf("MaxDuration for module %v can not be negative", module)
+		}
+	}
+	for _, set := range c.Tests {
+		for _, tests := range set.GetAllModuleTests() {
+			for _, test := range tests {
+				if test.GetMaxDuration() < 0 {
+					return fmt.Errorf("test %v in test set '%v' has a negative MaxDuration", test.String(), set.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// the MaxDuration of the test, or the default for its module and operation, 0 if there is no limit
+func (c *TestConfig) GetMaxDuration(CRUD string, test TestRunner) float64 {
+	if test.GetMaxDuration() > 0 {
+		return test.GetMaxDuration()
+	}
+	return c.MaxDuration[test.GetModule()].get(CRUD)
+}
+
+// a passed test which took longer than its MaxDuration is slow, or failed with StrictTiming
+func (c *TestConfig) checkDuration(CRUD string, test TestRunner, result *TestResult) {
+	limit := c.GetMaxDuration(CRUD, test)
+	if result.Result != TST_PASS || limit <= 0 || result.Duration <= limit {
+		return
+	}
+
+	result.Reason = fmt.Sprintf("took %.2fs, longer than the maximum of %gs", result.Duration, limit)
+	if c.StrictTiming {
+		result.Result = TST_FAIL
+	} else {
+		result.Result = TST_SLOW
+	}
+}
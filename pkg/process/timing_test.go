@@ -0,0 +1,77 @@
+package process
+
+import (
+	"testing"
+
+	"github.com/cxpsemea/cx1e2e/pkg/types"
+	"gopkg.in/yaml.v2"
+)
+
+func TestCheckDuration(t *testing.T) {
+	budgets := map[string]DurationBudget{fakeModule: {Create: 2, Read: 1}}
+	tests := []struct {
+		name        string
+		CRUD        string
+		maxDuration float64 // of the test
+		strict      bool
+		result      int
+		duration    float64
+		expected    int
+		limit       float64
+	}{
+		{"within the module default", types.OP_CREATE, 0, false, TST_PASS, 1.5, TST_PASS, 2},
+		{"longer than the module default", types.OP_CREATE, 0, false, TST_PASS, 2.5, TST_SLOW, 2},
+		{"longer than the module default with strict timing", types.OP_CREATE, 0, true, TST_PASS, 2.5, TST_FAIL, 2},
+		{"per-operation default", types.OP_READ, 0, false, TST_PASS, 1.5, TST_SLOW, 1},
+		{"no default for the operation", types.OP_DELETE, 0, false, TST_PASS, 100, TST_PASS, 0},
+		{"test maximum overrides a shorter default", types.OP_CREATE, 5, false, TST_PASS, 2.5, TST_PASS, 5},
+		{"test maximum overrides a longer default", types.OP_CREATE, 1, false, TST_PASS, 1.5, TST_SLOW, 1},
+		{"failed tests stay failed", types.OP_CREATE, 0, false, TST_FAIL, 2.5, TST_FAIL, 2},
+		{"skipped tests stay skipped", types.OP_CREATE, 0, true, TST_SKIP, 2.5, TST_SKIP, 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := TestConfig{MaxDuration: budgets, StrictTiming: test.strict}
+			fake := &fakeTest{Name: "fake"}
+			fake.MaxDuration = test.maxDuration
+
+			if limit := config.GetMaxDuration(test.CRUD, fake); limit != test.limit {
+				t.Errorf("expected a maximum duration of %v, got %v", test.limit, limit)
+			}
+			result := TestResult{Result: test.result, Duration: test.duration}
+			config.checkDuration(test.CRUD, fake, &result)
+			if result.Result != test.expected {
+				t.Errorf("expected %v, got %v (%v)", resultName(test.expected), resultName(result.Result), result.Reason)
+			}
+		})
+	}
+}
+
+func TestValidateDurations(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{"valid", "MaxDuration:\n  Fake: { Create: 1 }\nTests:\n  - Name: a\n    Fakes: [ { Name: a, Test: C, MaxDuration: 2 } ]\n", ""},
+		{"unknown module", "MaxDuration:\n  Unknown: { Create: 1 }\nTests: []\n", "MaxDuration is defined for unknown module 'Unknown'"},
+		{"negative default", "MaxDuration:\n  Fake: { Read: -1 }\nTests: []\n", "MaxDuration for module Fake can not be negative"},
+		{"negative test maximum", "Tests:\n  - Name: a\n    Fakes: [ { Name: a, Test: C, MaxDuration: -2 } ]\n", "test a in test set 'a' has a negative MaxDuration"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var config TestConfig
+			if err := yaml.UnmarshalStrict([]byte(test.config), &config); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			err := config.validateDurations()
+			if test.err == "" && err != nil {
+				t.Errorf("expected no error, got %s", err)
+			} else if test.err != "" && (err == nil || err.Error() != test.err) {
+				t.Errorf("expected error %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cxpsemea/Cx1ClientGo"
@@ -15,11 +16,11 @@ func CheckAMFlag(cx1client *Cx1ClientGo.Cx1Client) bool {
 	return flag
 }
 
-func (t *AccessAssignmentCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *AccessAssignmentCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	return nil
 }
 
-func (t *AccessAssignmentCRUD) Validate(CRUD string) error {
+func (t *AccessAssignmentCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.EntityType == "" || t.EntityName == "" {
 		return fmt.Errorf("entity type or name is missing")
 	}
@@ -90,7 +91,7 @@ func prepareAccessAssignment(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 	return access, nil
 }
 
-func (t *AccessAssignmentCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *AccessAssignmentCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	access, err := prepareAccessAssignment(cx1client, logger, t)
 	if err != nil {
 		return err
@@ -101,10 +102,20 @@ func (t *AccessAssignmentCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logge
 		return err
 	}
 
+	Registry.Add(accessAssignmentObject(access))
 	return nil
 }
 
-func (t *AccessAssignmentCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func accessAssignmentObject(access Cx1ClientGo.AccessAssignment) CreatedObject {
+	return CreatedObject{
+		Module:   MOD_ACCESS,
+		ID:       access.EntityID,
+		Name:     fmt.Sprintf("%v %v", access.EntityType, access.EntityName),
+		ParentID: access.ResourceID,
+	}
+}
+
+func (t *AccessAssignmentCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	access, err := prepareAccessAssignment(cx1client, logger, t)
 	if err != nil {
 		return err
@@ -131,7 +142,7 @@ func (t *AccessAssignmentCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger
 	return nil
 }
 
-func (t *AccessAssignmentCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *AccessAssignmentCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	access, err := prepareAccessAssignment(cx1client, logger, t)
 	if err != nil {
 		return err
@@ -151,7 +162,7 @@ func (t *AccessAssignmentCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logge
 	return nil
 }
 
-func (t *AccessAssignmentCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *AccessAssignmentCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	access, err := prepareAccessAssignment(cx1client, logger, t)
 	if err != nil {
 		return err
@@ -163,5 +174,10 @@ func (t *AccessAssignmentCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logge
 		return fmt.Errorf("failed to remove existing %v %v access to %v %v", t.EntityType, t.EntityName, t.ResourceType, t.ResourceName)
 	}
 
+	Registry.Remove(accessAssignmentObject(access))
 	return nil
 }
+
+func (t *AccessAssignmentCRUD) DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object CreatedObject) error {
+	return cx1client.DeleteAccessAssignmentByID(object.ID, object.ParentID)
+}
@@ -1,24 +1,26 @@
 package types
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *ApplicationCRUD) Validate(CRUD string) error {
-	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Application == nil {
-		return fmt.Errorf("must read before updating or deleting")
-	}
+func (t *ApplicationCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.Name == "" {
 		return fmt.Errorf("application name is missing")
 	}
 
+	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Application == nil {
+		return fmt.Errorf("%w before updating or deleting", ErrNotRead)
+	}
+
 	return nil
 }
 
-func (t *ApplicationCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *ApplicationCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	return nil
 }
 
@@ -43,7 +45,7 @@ func updateApplication(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger,
 	return nil
 }
 
-func (t *ApplicationCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ApplicationCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	/* TODO once apps can be in groups
 	group_ids := []string{}
 
@@ -59,6 +61,7 @@ func (t *ApplicationCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *lo
 		return err
 	}
 	t.Application = &test_Application
+	Registry.Add(CreatedObject{Module: MOD_APPLICATION, ID: test_Application.ApplicationID, Name: test_Application.Name})
 
 	err = updateApplication(cx1client, logger, t)
 	if err != nil {
@@ -68,7 +71,7 @@ func (t *ApplicationCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *lo
 	return nil
 }
 
-func (t *ApplicationCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ApplicationCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	test_Application, err := cx1client.GetApplicationByName(t.Name)
 	if err != nil {
 		return err
@@ -77,7 +80,7 @@ func (t *ApplicationCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logr
 	return nil
 }
 
-func (t *ApplicationCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ApplicationCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	err := updateApplication(cx1client, logger, t)
 	if err != nil {
 		return err
@@ -85,12 +88,17 @@ func (t *ApplicationCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *lo
 	return nil
 }
 
-func (t *ApplicationCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ApplicationCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	err := cx1client.DeleteApplicationByID(t.Application.ApplicationID)
 	if err != nil {
 		return err
 	}
+	Registry.Remove(CreatedObject{Module: MOD_APPLICATION, ID: t.Application.ApplicationID})
 
 	t.Application = nil
 	return nil
 }
+
+func (t *ApplicationCRUD) DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object CreatedObject) error {
+	return cx1client.DeleteApplicationByID(object.ID)
+}
@@ -0,0 +1,204 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// a check of a field of the object fetched by a [R]ead test
+type Assertion struct {
+	Field    string  `yaml:"Field"`    // path of a field of the test object as used by Capture, eg: User.Email or Application.Tags.env
+	Equals   *string `yaml:"Equals"`   // the field has this value
+	Contains *string `yaml:"Contains"` // the text contains this, a list has an element with this name or value, or a map has this key
+	Matches  *string `yaml:"Matches"`  // the field matches this regular expression
+	Count    *int    `yaml:"Count"`    // the list, map or text has this many elements
+	Absent   bool    `yaml:"Absent"`   // the field is not set or empty, or together with Contains: the element is not there
+}
+
+func (a Assertion) String() string {
+	switch {
+	case a.Equals != nil:
+		return fmt.Sprintf("%v equals '%v'", a.Field, *a.Equals)
+	case a.Contains != nil && a.Absent:
+		return fmt.Sprintf("%v does not contain '%v'", a.Field, *a.Contains)
+	case a.Contains != nil:
+		return fmt.Sprintf("%v contains '%v'", a.Field, *a.Contains)
+	case a.Matches != nil:
+		return fmt.Sprintf("%v matches '%v'", a.Field, *a.Matches)
+	case a.Count != nil:
+		return fmt.Sprintf("%v has %d elements", a.Field, *a.Count)
+	}
+	return fmt.Sprintf("%v is absent", a.Field)
+}
+
+// checks that the assertion can be evaluated against objects of the given type
+func (a Assertion) Validate(objectType reflect.Type) error {
+	if a.Field == "" {
+		return fmt.Errorf("assertion has no Field")
+	}
+
+	checks := 0
+	for _, set := range []bool{a.Equals != nil, a.Contains != nil, a.Matches != nil, a.Count != nil} {
+		if set {
+			checks++
+		}
+	}
+	if checks > 1 || (checks == 0 && !a.Absent) || (a.Absent && checks == 1 && a.Contains == nil) {
+		return fmt.Errorf("assertion on %v must have one of Equals, Contains, Matches, Count or Absent, Absent can only be combined with Contains", a.Field)
+	}
+
+	fieldType, err := resolvePathType(objectType, a.Field)
+	if err != nil {
+		return fmt.Errorf("can not check %v: %s", a.Field, err)
+	}
+
+	kind := fieldType.Kind()
+	switch {
+	case a.Equals != nil && !isSimpleKind(kind):
+		return fmt.Errorf("can not compare %v to a value, it is a %v", a.Field, fieldType)
+	case a.Matches != nil && !isSimpleKind(kind):
+		return fmt.Errorf("can not match %v against a regular expression, it is a %v", a.Field, fieldType)
+	case (a.Contains != nil || a.Count != nil) && !isCollectionKind(kind):
+		return fmt.Errorf("%v is a %v, Contains and Count need text, a list or a map", a.Field, fieldType)
+	}
+
+	if a.Matches != nil {
+		if _, err := regexp.Compile(*a.Matches); err != nil {
+			return fmt.Errorf("invalid regular expression %v: %s", *a.Matches, err)
+		}
+	}
+	if a.Count != nil && *a.Count < 0 {
+		return fmt.Errorf("assertion on %v has a negative Count", a.Field)
+	}
+	return nil
+}
+
+// returns a description of the failure, or an empty string if the assertion holds
+func (a Assertion) Check(object interface{}) string {
+	value, err := resolvePath(object, a.Field)
+	if a.Absent && a.Contains == nil {
+		if err != nil || isEmpty(value) {
+			return ""
+		}
+		return fmt.Sprintf("expected %v to be absent, got %v", a.Field, describe(value))
+	}
+	if err != nil {
+		return fmt.Sprintf("can not check that %v: %s", a.String(), err)
+	}
+
+	switch {
+	case a.Equals != nil:
+		if actual := fmt.Sprintf("%v", value.Interface()); actual != *a.Equals {
+			return fmt.Sprintf("expected %v to equal '%v', got '%v'", a.Field, *a.Equals, actual)
+		}
+	case a.Matches != nil:
+		// the expression is only known once variables have been substituted
+		pattern, err := regexp.Compile(*a.Matches)
+		if err != nil {
+			return fmt.Sprintf("can not check that %v: invalid regular expression: %s", a.String(), err)
+		}
+		if actual := fmt.Sprintf("%v", value.Interface()); !pattern.MatchString(actual) {
+			return fmt.Sprintf("expected %v to match '%v', got '%v'", a.Field, *a.Matches, actual)
+		}
+	case a.Count != nil:
+		if value.Len() != *a.Count {
+			return fmt.Sprintf("expected %v to have %d elements, got %d: %v", a.Field, *a.Count, value.Len(), describe(value))
+		}
+	case a.Contains != nil:
+		found := contains(value, *a.Contains)
+		if a.Absent && found {
+			return fmt.Sprintf("expected %v not to contain '%v', got %v", a.Field, *a.Contains, describe(value))
+		}
+		if !a.Absent && !found {
+			return fmt.Sprintf("expected %v to contain '%v', got %v", a.Field, *a.Contains, describe(value))
+		}
+	}
+	return ""
+}
+
+// checks all assertions against the object and returns an error listing every failed assertion
+func CheckAssertions(object interface{}, assertions []Assertion) error {
+	failures := []string{}
+	for _, a := range assertions {
+		if failure := a.Check(object); failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+
+	switch len(failures) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("assertion failed: %v", failures[0])
+	}
+	return fmt.Errorf("%d assertions failed: %v", len(failures), strings.Join(failures, "; "))
+}
+
+func isCollectionKind(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+}
+
+func isEmpty(value reflect.Value) bool {
+	if isCollectionKind(value.Kind()) {
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func contains(value reflect.Value, element string) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.Contains(value.String(), element)
+	case reflect.Map:
+		return value.MapIndex(reflect.ValueOf(element)).IsValid()
+	}
+	for id := 0; id < value.Len(); id++ {
+		if elementName(value.Index(id)) == element {
+			return true
+		}
+	}
+	return false
+}
+
+// lists are described by the names of their elements and maps by their keys
+func describe(value reflect.Value) string {
+	var names []string
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for id := 0; id < value.Len(); id++ {
+			names = append(names, elementName(value.Index(id)))
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			names = append(names, fmt.Sprintf("%v", key.Interface()))
+		}
+		sort.Strings(names)
+	default:
+		return fmt.Sprintf("'%v'", value.Interface())
+	}
+	return fmt.Sprintf("[%v]", strings.Join(names, ", "))
+}
+
+// elements of a list are identified by their Name field if they have one, eg: the groups of a user
+func elementName(value reflect.Value) string {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+	if value.Kind() == reflect.Struct {
+		if field, ok := findField(value.Type(), "Name"); ok && field.Type.Kind() == reflect.String {
+			return value.FieldByIndex(field.Index).String()
+		}
+		if value.CanAddr() {
+			if stringer, ok := value.Addr().Interface().(fmt.Stringer); ok {
+				return stringer.String()
+			}
+		}
+	}
+	return fmt.Sprintf("%v", value.Interface())
+}
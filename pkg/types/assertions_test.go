@@ -0,0 +1,121 @@
+package types
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type assertionGroup struct {
+	Name string
+}
+
+type assertionObject struct {
+	Name   string
+	Email  string
+	Count  int
+	Tags   map[string]string
+	Groups []assertionGroup
+}
+
+func TestAssertionCheck(t *testing.T) {
+	object := &assertionObject{
+		Name:   "e2e-user",
+		Count:  3,
+		Tags:   map[string]string{"env": "dev"},
+		Groups: []assertionGroup{{Name: "admins"}, {Name: "devs"}},
+	}
+	count := func(count int) *int { return &count }
+
+	tests := []struct {
+		name      string
+		assertion Assertion
+		failure   string
+	}{
+		{"equals", Assertion{Field: "Name", Equals: strPtr("e2e-user")}, ""},
+		{"equals a number", Assertion{Field: "count", Equals: strPtr("3")}, ""},
+		{"not equal", Assertion{Field: "Name", Equals: strPtr("other")}, "expected Name to equal 'other', got 'e2e-user'"},
+		{"matches", Assertion{Field: "Name", Matches: strPtr("^e2e-")}, ""},
+		{"does not match", Assertion{Field: "Name", Matches: strPtr("^x")}, "expected Name to match '^x'"},
+		{"invalid expression", Assertion{Field: "Name", Matches: strPtr("(")}, "invalid regular expression"},
+		{"list contains", Assertion{Field: "Groups", Contains: strPtr("devs")}, ""},
+		{"list does not contain", Assertion{Field: "Groups", Contains: strPtr("ops")}, "expected Groups to contain 'ops', got [admins, devs]"},
+		{"map contains key", Assertion{Field: "Tags", Contains: strPtr("env")}, ""},
+		{"text contains", Assertion{Field: "Name", Contains: strPtr("user")}, ""},
+		{"absent element", Assertion{Field: "Groups", Contains: strPtr("admins"), Absent: true}, "expected Groups not to contain 'admins'"},
+		{"count", Assertion{Field: "Groups", Count: count(2)}, ""},
+		{"wrong count", Assertion{Field: "Tags", Count: count(2)}, "expected Tags to have 2 elements, got 1: [env]"},
+		{"absent field", Assertion{Field: "Email", Absent: true}, ""},
+		{"missing map key is absent", Assertion{Field: "Tags.team", Absent: true}, ""},
+		{"present field", Assertion{Field: "Name", Absent: true}, "expected Name to be absent"},
+		{"map value", Assertion{Field: "Tags.env", Equals: strPtr("dev")}, ""},
+		{"missing map key", Assertion{Field: "Tags.team", Equals: strPtr("red")}, "can not check that Tags.team equals 'red'"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			failure := test.assertion.Check(object)
+			if test.failure == "" && failure != "" {
+				t.Errorf("expected the assertion to hold, got %v", failure)
+			} else if !strings.Contains(failure, test.failure) {
+				t.Errorf("expected a failure containing %q, got %q", test.failure, failure)
+			}
+		})
+	}
+}
+
+func TestAssertionValidate(t *testing.T) {
+	count := -1
+	tests := []struct {
+		name      string
+		assertion Assertion
+		err       string
+	}{
+		{"no field", Assertion{Equals: strPtr("x")}, "has no Field"},
+		{"no check", Assertion{Field: "Name"}, "must have one of"},
+		{"two checks", Assertion{Field: "Name", Equals: strPtr("x"), Matches: strPtr("x")}, "must have one of"},
+		{"absent with equals", Assertion{Field: "Name", Equals: strPtr("x"), Absent: true}, "must have one of"},
+		{"unknown field", Assertion{Field: "Missing", Absent: true}, "can not check Missing"},
+		{"equals on a list", Assertion{Field: "Groups", Equals: strPtr("x")}, "can not compare Groups"},
+		{"matches on a map", Assertion{Field: "Tags", Matches: strPtr("x")}, "can not match Tags"},
+		{"count of a number", Assertion{Field: "Count", Count: &count}, "Contains and Count need text"},
+		{"negative count", Assertion{Field: "Groups", Count: &count}, "negative Count"},
+		{"invalid expression", Assertion{Field: "Name", Matches: strPtr("(")}, "invalid regular expression"},
+		{"variable in expression", Assertion{Field: "Name", Matches: strPtr("^${prefix}-")}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.assertion.Validate(reflect.TypeOf(&assertionObject{}))
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+// the expression is only compiled when the test runs, after the variables were substituted
+func TestAssertionSubstitutedPattern(t *testing.T) {
+	test := &GroupCRUD{Name: "group"}
+	test.Expect = []Assertion{{Field: "Name", Matches: strPtr("${pattern}")}, {Field: "Name", Equals: strPtr("group")}}
+	if err := test.Expect[0].Validate(reflect.TypeOf(test)); err != nil {
+		t.Fatalf("unexpected validation error: %s", err)
+	}
+
+	variables := map[string]string{"pattern": "group-(["}
+	if err := SubstituteVariables(test, func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	err := CheckAssertions(test, test.Expect)
+	if err == nil || !strings.Contains(err.Error(), "assertion failed: can not check that Name matches 'group-([': invalid regular expression") {
+		t.Errorf("expected the invalid expression to fail the assertion, got %v", err)
+	}
+}
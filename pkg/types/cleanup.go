@@ -0,0 +1,100 @@
+package types
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+	"github.com/sirupsen/logrus"
+)
+
+// an object created by a test, with enough details to delete it again without the original test
+type CreatedObject struct {
+	Module   string
+	ID       string
+	Name     string
+	ParentID string // access assignments: resource ID, queries: level ID
+	Level    string // queries only
+	Language string // queries only
+	Group    string // queries only
+}
+
+// implemented by the tests of modules whose objects can be deleted by automatic teardown, used as the default teardown of the module
+type ObjectDeleter interface {
+	DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object CreatedObject) error
+}
+
+// keeps track of the objects created during a run which have not been deleted yet
+type CleanupRegistry struct {
+	lock    sync.Mutex
+	objects []CreatedObject
+	removed []CreatedObject // so that the changes made by a single operation can be applied to another registry
+}
+
+func (o CreatedObject) String() string {
+	switch o.Module {
+	case MOD_ACCESS:
+		return fmt.Sprintf("%v access to %v", o.Name, o.ParentID)
+	case MOD_QUERY:
+		return fmt.Sprintf("%v: %v -> %v -> %v", o.Level, o.Language, o.Group, o.Name)
+	}
+	return fmt.Sprintf("%v (%v)", o.Name, o.ID)
+}
+
+func (r *CleanupRegistry) Add(object CreatedObject) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.objects = append(r.objects, object)
+}
+
+// forget an object which was deleted by a test
+func (r *CleanupRegistry) Remove(object CreatedObject) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.removed = append(r.removed, object)
+	for id := len(r.objects) - 1; id >= 0; id-- {
+		o := r.objects[id]
+		if o.Module == object.Module && o.ID == object.ID && o.ParentID == object.ParentID {
+			r.objects = append(r.objects[:id], r.objects[id+1:]...)
+		}
+	}
+}
+
+// deletes the object with the teardown of its module and forgets it once it is deleted
+func (r *CleanupRegistry) Teardown(object CreatedObject, teardown func(object CreatedObject) error) error {
+	if err := teardown(object); err != nil {
+		return err
+	}
+	r.Remove(object)
+	return nil
+}
+
+// returns the remaining objects in the order in which they were created
+func (r *CleanupRegistry) GetObjects() []CreatedObject {
+	if r == nil {
+		return []CreatedObject{}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]CreatedObject{}, r.objects...)
+}
+
+// applies the objects which were added to and removed from the registry of a single operation
+func (r *CleanupRegistry) Apply(operation *CleanupRegistry) {
+	operation.lock.Lock()
+	added, removed := append([]CreatedObject{}, operation.objects...), append([]CreatedObject{}, operation.removed...)
+	operation.lock.Unlock()
+
+	for _, object := range removed {
+		r.Remove(object)
+	}
+	for _, object := range added {
+		r.Add(object)
+	}
+}
@@ -1,13 +1,14 @@
 package types
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *FlagCRUD) Validate(CRUD string) error {
+func (t *FlagCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.Name == "" {
 		return fmt.Errorf("flag name is missing")
 	}
@@ -15,7 +16,7 @@ func (t *FlagCRUD) Validate(CRUD string) error {
 	return nil
 }
 
-func (t *FlagCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *FlagCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	if CRUD != OP_READ {
 		return fmt.Errorf("can only read flags")
 	}
@@ -26,11 +27,11 @@ func (t *FlagCRUD) GetModule() string {
 	return MOD_FLAG
 }
 
-func (t *FlagCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *FlagCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	return fmt.Errorf("not supported")
 }
 
-func (t *FlagCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *FlagCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	test_Flag, err := cx1client.CheckFlag(t.Name)
 	if err != nil {
 		return err
@@ -45,10 +46,10 @@ func (t *FlagCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logg
 	return nil
 }
 
-func (t *FlagCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *FlagCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	return fmt.Errorf("not supported")
 }
 
-func (t *FlagCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *FlagCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	return fmt.Errorf("not supported")
 }
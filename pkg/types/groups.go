@@ -1,25 +1,26 @@
 package types
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *GroupCRUD) Validate(CRUD string) error {
-	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Group == nil {
-		return fmt.Errorf("must read before updating or deleting")
-	}
-
+func (t *GroupCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.Name == "" {
 		return fmt.Errorf("group name is missing")
 	}
 
+	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Group == nil {
+		return fmt.Errorf("%w before updating or deleting", ErrNotRead)
+	}
+
 	return nil
 }
 
-func (t *GroupCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *GroupCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	return nil
 }
 
@@ -57,11 +58,12 @@ func updateGroup(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, t *Gro
 	return nil
 }
 
-func (t *GroupCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *GroupCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	test_Group, err := cx1client.CreateGroup(t.Name)
 	if err != nil {
 		return err
 	}
+	Registry.Add(CreatedObject{Module: MOD_GROUP, ID: test_Group.GroupID, Name: test_Group.Name})
 	test_Group, err = cx1client.GetGroupByID(test_Group.GroupID)
 	if err != nil {
 		return err
@@ -76,7 +78,7 @@ func (t *GroupCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.L
 	return nil
 }
 
-func (t *GroupCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *GroupCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	test_Group, err := cx1client.GetGroupByName(t.Name)
 	if err != nil {
 		return err
@@ -91,7 +93,7 @@ func (t *GroupCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Log
 	return nil
 }
 
-func (t *GroupCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *GroupCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	err := updateGroup(cx1client, logger, t)
 	if err != nil {
 		return err
@@ -100,12 +102,17 @@ func (t *GroupCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.L
 	return nil
 }
 
-func (t *GroupCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *GroupCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	err := cx1client.DeleteGroup(t.Group)
 	if err != nil {
 		return err
 	}
+	Registry.Remove(CreatedObject{Module: MOD_GROUP, ID: t.Group.GroupID})
 
 	t.Group = nil
 	return nil
 }
+
+func (t *GroupCRUD) DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object CreatedObject) error {
+	return cx1client.DeleteGroup(&Cx1ClientGo.Group{GroupID: object.ID, Name: object.Name})
+}
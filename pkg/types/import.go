@@ -1,14 +1,16 @@
 package types
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *ImportCRUD) Validate(CRUD string) error {
+func (t *ImportCRUD) Validate(ctx context.Context, CRUD string) error {
 	if CRUD != OP_CREATE {
 		return fmt.Errorf("test type is not supported")
 	}
@@ -24,7 +26,7 @@ func (t *ImportCRUD) Validate(CRUD string) error {
 	return nil
 }
 
-func (t *ImportCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *ImportCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	if CRUD != OP_CREATE {
 		return fmt.Errorf("can only create an import")
 	}
@@ -35,7 +37,7 @@ func (t *ImportCRUD) GetModule() string {
 	return MOD_IMPORT
 }
 
-func (t *ImportCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ImportCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	fileContents, err := os.ReadFile(t.ZipFile)
 	if err != nil {
 		return fmt.Errorf("failed to read %v: %s", t.ZipFile, err)
@@ -49,37 +51,120 @@ func (t *ImportCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.
 		}
 	}
 
+	if Registry != nil {
+		if projects, err := getAllProjects(cx1client); err != nil {
+			logger.Warnf("Failed to list the projects before the import, projects created by the import will not be deleted by automatic teardown: %s", err)
+		} else {
+			existing := make(map[string]bool)
+			for _, p := range projects {
+				existing[p.ProjectID] = true
+			}
+			defer registerImportedProjects(cx1client, logger, Registry, existing)
+		}
+	}
+
+	if err = ctx.Err(); err != nil { // listing the projects may take a while
+		return err
+	}
 	importID, err := cx1client.StartMigration(fileContents, projectMapping, t.EncryptionKey) // no project-to-app mapping
 	if err != nil {
 		return fmt.Errorf("failed to start import: %v", err)
 	}
 
-	var result string
-	if t.TimeoutSeconds != 0 {
-		cvars := cx1client.GetClientVars()
-		result, err = cx1client.ImportPollingByIDWithTimeout(importID, cvars.MigrationPollingDelaySeconds, t.TimeoutSeconds)
-	} else {
-		result, err = cx1client.ImportPollingByID(importID)
+	// the Timeout of the test limits the polling through ctx, otherwise the polling limit of the client applies
+	cvars := cx1client.GetClientVars()
+	maxSeconds := cvars.MigrationPollingMaxSeconds
+	if t.Timeout != 0 {
+		maxSeconds = 0
 	}
-	if err != nil {
+	if _, err = pollImport(ctx, cx1client, logger, importID, cvars.MigrationPollingDelaySeconds, maxSeconds); err != nil {
 		return fmt.Errorf("failed during import: %s", err)
 	}
+	return nil
+}
+
+// polls the status of the import until it finishes, the context is done or maxSeconds have passed, 0 for no limit
+func pollImport(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, importID string, delaySeconds, maxSeconds int) (string, error) {
+	logger.Infof("Polling status of import %v", importID)
+
+	polled := 0
+	for {
+		status, err := cx1client.GetImportByID(importID)
+		if err != nil {
+			return "", err
+		}
+		logger.Infof(" - %v", status.Status)
+
+		switch status.Status {
+		case "failed":
+			return status.Status, fmt.Errorf("import failed: %s", status.Logs)
+		case "completed", "partial":
+			return status.Status, nil
+		}
+		if maxSeconds != 0 && polled >= maxSeconds {
+			return "timeout", fmt.Errorf("import polling reached %d seconds, aborting", polled)
+		}
 
-	if result == "Failed" {
-		return fmt.Errorf("import failed")
+		select {
+		case <-ctx.Done():
+			return status.Status, fmt.Errorf("import polling aborted: %s", ctx.Err())
+		case <-time.After(time.Duration(delaySeconds) * time.Second):
+		}
+		polled += delaySeconds
 	}
+}
 
-	return nil
+func getAllProjects(cx1client *Cx1ClientGo.Cx1Client) ([]Cx1ClientGo.Project, error) {
+	count, err := cx1client.GetProjectCount()
+	if err != nil {
+		return nil, err
+	}
+	return cx1client.GetProjects(count)
 }
 
-func (t *ImportCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+// the import does not return the projects it created, so any project which did not exist before the import is registered,
+// also if the import failed part of the way - this includes projects created by tests running at the same time
+func registerImportedProjects(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Registry *CleanupRegistry, existing map[string]bool) {
+	projects, err := getAllProjects(cx1client)
+	if err != nil {
+		logger.Warnf("Failed to list the projects after the import, projects created by the import will not be deleted by automatic teardown: %s", err)
+		return
+	}
+	for _, p := range projects {
+		if !existing[p.ProjectID] {
+			logger.Debugf("Project %v was created by the import", p.String())
+			Registry.Add(CreatedObject{Module: MOD_PROJECT, ID: p.ProjectID, Name: p.Name})
+		}
+	}
+}
+
+func (t *ImportCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	return fmt.Errorf("not supported")
 }
 
-func (t *ImportCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ImportCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	return fmt.Errorf("not supported")
 }
 
-func (t *ImportCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ImportCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	return fmt.Errorf("not supported")
 }
+
+// makes the zip and project mapping files relative to the configuration file
+func (t *ImportCRUD) ResolveFiles(locate func(file string) (string, error)) error {
+	if t.ZipFile != "" {
+		filePath, err := locate(t.ZipFile)
+		if err != nil {
+			return fmt.Errorf("error locating import zipfile %v", t.ZipFile)
+		}
+		t.ZipFile = filePath
+	}
+	if t.ProjectMapFile != "" {
+		filePath, err := locate(t.ProjectMapFile)
+		if err != nil {
+			return fmt.Errorf("error locating import ProjectMapFile %v", t.ProjectMapFile)
+		}
+		t.ProjectMapFile = filePath
+	}
+	return nil
+}
@@ -1,25 +1,27 @@
 package types
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *PresetCRUD) Validate(CRUD string) error {
-	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Preset == nil {
-		return fmt.Errorf("must read before updating or deleting")
-	}
-
+func (t *PresetCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.Name == "" {
 		return fmt.Errorf("preset name is missing")
 	}
 
+	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Preset == nil {
+		return fmt.Errorf("%w before updating or deleting", ErrNotRead)
+	}
+
 	return nil
 }
 
-func (t *PresetCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *PresetCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	return nil
 }
 
@@ -45,7 +47,7 @@ func getQueryIDs(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, t *Pre
 	return query_ids, nil
 }
 
-func (t *PresetCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *PresetCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	query_ids, err := getQueryIDs(cx1client, logger, t)
 	if err != nil {
 		return err
@@ -56,10 +58,11 @@ func (t *PresetCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.
 		return err
 	}
 	t.Preset = &test_Preset
+	Registry.Add(CreatedObject{Module: MOD_PRESET, ID: strconv.FormatUint(test_Preset.PresetID, 10), Name: test_Preset.Name})
 	return nil
 }
 
-func (t *PresetCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *PresetCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	test_Preset, err := cx1client.GetPresetByName(t.Name)
 	if err != nil {
 		return err
@@ -68,7 +71,7 @@ func (t *PresetCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 	return nil
 }
 
-func (t *PresetCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *PresetCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	query_ids, err := getQueryIDs(cx1client, logger, t)
 	if err != nil {
 		return err
@@ -79,12 +82,25 @@ func (t *PresetCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.
 	return err
 }
 
-func (t *PresetCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *PresetCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	err := cx1client.DeletePreset(t.Preset)
 	if err != nil {
 		return err
 	}
+	Registry.Remove(CreatedObject{Module: MOD_PRESET, ID: strconv.FormatUint(t.Preset.PresetID, 10)})
 
 	t.Preset = nil
 	return nil
 }
+
+func (t *PresetCRUD) DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object CreatedObject) error {
+	id, err := strconv.ParseUint(object.ID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid preset ID %v: %s", object.ID, err)
+	}
+	preset, err := cx1client.GetPresetByID(id)
+	if err != nil {
+		return err
+	}
+	return cx1client.DeletePreset(&preset)
+}
@@ -1,25 +1,26 @@
 package types
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *ProjectCRUD) Validate(CRUD string) error {
-	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Project == nil {
-		return fmt.Errorf("must read before updating or deleting")
-	}
-
+func (t *ProjectCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.Name == "" {
 		return fmt.Errorf("project name is missing")
 	}
 
+	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Project == nil {
+		return fmt.Errorf("%w before updating or deleting", ErrNotRead)
+	}
+
 	return nil
 }
 
-func (t *ProjectCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *ProjectCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	return nil
 }
 
@@ -27,7 +28,7 @@ func (t *ProjectCRUD) GetModule() string {
 	return MOD_PROJECT
 }
 
-func (t *ProjectCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ProjectCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	group_ids := []string{}
 
 	for _, g := range t.Groups {
@@ -61,10 +62,11 @@ func (t *ProjectCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus
 		t.Project = &test_Project
 	}
 
+	Registry.Add(CreatedObject{Module: MOD_PROJECT, ID: t.Project.ProjectID, Name: t.Project.Name})
 	return nil
 }
 
-func (t *ProjectCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ProjectCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	test_Project, err := cx1client.GetProjectByName(t.Name)
 	if err != nil {
 		return err
@@ -90,7 +92,7 @@ func (t *ProjectCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.L
 	return nil
 }
 
-func (t *ProjectCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ProjectCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	if t.Application != "" {
 		app, err := cx1client.GetApplicationByName(t.Application)
 		if err != nil {
@@ -117,12 +119,17 @@ func (t *ProjectCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus
 	return nil
 }
 
-func (t *ProjectCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ProjectCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	err := cx1client.DeleteProject(t.Project)
 	if err != nil {
 		return err
 	}
+	Registry.Remove(CreatedObject{Module: MOD_PROJECT, ID: t.Project.ProjectID})
 
 	t.Project = nil
 	return nil
 }
+
+func (t *ProjectCRUD) DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object CreatedObject) error {
+	return cx1client.DeleteProject(&Cx1ClientGo.Project{ProjectID: object.ID, Name: object.Name})
+}
@@ -1,17 +1,14 @@
 package types
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *CxQLCRUD) Validate(CRUD string) error {
-	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Query == nil {
-		return fmt.Errorf("must read before updating or deleting")
-	}
-
+func (t *CxQLCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.QueryLanguage == "" || t.QueryGroup == "" || t.QueryName == "" {
 		return fmt.Errorf("query language, group, or name is missing")
 	}
@@ -20,10 +17,14 @@ func (t *CxQLCRUD) Validate(CRUD string) error {
 		return fmt.Errorf("project name is missing")
 	}
 
+	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Query == nil {
+		return fmt.Errorf("%w before updating or deleting", ErrNotRead)
+	}
+
 	return nil
 }
 
-func (t *CxQLCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *CxQLCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	return nil
 }
 
@@ -112,7 +113,8 @@ func compileQuery(cx1client *Cx1ClientGo.Cx1Client, query *Cx1ClientGo.AuditQuer
 	return nil
 }
 
-func updateQuery(cx1client *Cx1ClientGo.Cx1Client, sessionId string, t *CxQLCRUD) error {
+// the compilation can not be canceled, so the query is only changed if the test was not canceled in the meantime
+func updateQuery(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, sessionId string, t *CxQLCRUD) error {
 	t.Query.Severity = cx1client.GetSeverityID(t.Severity)
 
 	if t.Source != "" {
@@ -128,6 +130,9 @@ func updateQuery(cx1client *Cx1ClientGo.Cx1Client, sessionId string, t *CxQLCRUD
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	if t.OldAPI {
 		return cx1client.UpdateQuery(*t.Query)
 	} else {
@@ -144,7 +149,7 @@ func (t *CxQLCRUD) TerminateSession(cx1client *Cx1ClientGo.Cx1Client, logger *lo
 	}
 }
 
-func (t *CxQLCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *CxQLCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	t.Query = getQuery(cx1client, logger, t)
 
 	var session string
@@ -160,6 +165,7 @@ func (t *CxQLCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 
 	if t.Query != nil {
 		logger.Debugf("Found query: %v", t.Query.String())
+		found := *t.Query
 
 		if t.Scope.Corp {
 			//logger.Info("Will create corp override")
@@ -178,8 +184,16 @@ func (t *CxQLCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 		}
 
 		logger.Debugf("Updating query %v", t.Query.String())
-		err = updateQuery(cx1client, session, t)
-		return err
+		err = updateQuery(ctx, cx1client, session, t)
+		if err != nil {
+			return err
+		}
+		if isExistingOverride(found, *t.Query) {
+			logger.Debugf("Query %v was already overridden before the test and will not be deleted by automatic teardown", t.Query.String())
+		} else {
+			Registry.Add(t.createdObject())
+		}
+		return nil
 	} else {
 		// query does not exist at all so needs to be created on corp level
 		// Second query: create new corp/tenant query
@@ -202,18 +216,46 @@ func (t *CxQLCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 				return err
 			}
 		}
+		if err = ctx.Err(); err != nil {
+			return err
+		}
 
 		newQuery, err = cx1client.AuditCreateCorpQuery(session, newQuery)
 		if err != nil {
 			return err
 		}
 		t.Query = &newQuery
+		Registry.Add(t.createdObject())
 
 		return nil
 	}
 }
 
-func (t *CxQLCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+// the query found for the scope of the test is inherited from a higher level unless it already is an override on the same level
+func isExistingOverride(found, override Cx1ClientGo.AuditQuery) bool {
+	if found.Level != override.Level {
+		return false
+	}
+	return override.Level == "Corp" || found.LevelID == override.LevelID
+}
+
+func (t *CxQLCRUD) createdObject() CreatedObject {
+	levelID := t.Query.LevelID
+	if levelID == "" {
+		levelID = t.ScopeID
+	}
+	return CreatedObject{
+		Module:   MOD_QUERY,
+		ID:       fmt.Sprintf("%v/%v/%v/%v", levelID, t.QueryLanguage, t.QueryGroup, t.QueryName),
+		Name:     t.QueryName,
+		ParentID: levelID,
+		Level:    t.Query.Level,
+		Language: t.QueryLanguage,
+		Group:    t.QueryGroup,
+	}
+}
+
+func (t *CxQLCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	query := getQuery(cx1client, logger, t)
 	if query == nil {
 		return fmt.Errorf("no such query %v: %v -> %v -> %v exists", t.Scope, t.QueryLanguage, t.QueryGroup, t.QueryName)
@@ -238,16 +280,25 @@ func (t *CxQLCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logg
 	return nil
 }
 
-func (t *CxQLCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *CxQLCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	session, err := getAuditSession(cx1client, t)
 	if err != nil {
 		return err
 	}
 	defer t.TerminateSession(cx1client, logger, session)
-	err = updateQuery(cx1client, session, t)
+	err = updateQuery(ctx, cx1client, session, t)
 	return err
 }
 
-func (t *CxQLCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
-	return cx1client.DeleteQuery(*t.Query)
+func (t *CxQLCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
+	err := cx1client.DeleteQuery(*t.Query)
+	if err != nil {
+		return err
+	}
+	Registry.Remove(t.createdObject())
+	return nil
+}
+
+func (t *CxQLCRUD) DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object CreatedObject) error {
+	return cx1client.DeleteQueryByName(object.Level, object.ParentID, object.Language, object.Group, object.Name)
 }
@@ -0,0 +1,37 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+)
+
+func TestIsExistingOverride(t *testing.T) {
+	cx := Cx1ClientGo.AuditQuery{Level: "Cx", LevelID: "Cx"}
+	corp := Cx1ClientGo.AuditQuery{Level: "Corp", LevelID: "Corp"}
+	project := Cx1ClientGo.AuditQuery{Level: "Project", LevelID: "project-1"}
+
+	tests := []struct {
+		name     string
+		found    Cx1ClientGo.AuditQuery
+		override Cx1ClientGo.AuditQuery
+		existing bool
+	}{
+		{"new tenant override of a product query", cx, cx.CreateTenantOverride(), false},
+		{"tenant override exists", corp, corp.CreateTenantOverride(), true},
+		{"tenant override without a level ID", Cx1ClientGo.AuditQuery{Level: "Corp"}, corp.CreateTenantOverride(), true},
+		{"new project override of a tenant query", corp, corp.CreateProjectOverrideByID("project-1"), false},
+		{"project override exists", project, project.CreateProjectOverrideByID("project-1"), true},
+		{"override of another project", project, project.CreateProjectOverrideByID("project-2"), false},
+		{"new application override of a project query", project, project.CreateApplicationOverrideByID("app-1"), false},
+		{"application override exists", Cx1ClientGo.AuditQuery{Level: "Team", LevelID: "app-1"}, cx.CreateApplicationOverrideByID("app-1"), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if existing := isExistingOverride(test.found, test.override); existing != test.existing {
+				t.Errorf("expected %v, got %v", test.existing, existing)
+			}
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package types
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *ReportCRUD) Validate(CRUD string) error {
+// how long to wait between the checks of the status of a report which is being generated
+const reportPollingDelay = 10 * time.Second
+
+func (t *ReportCRUD) Validate(ctx context.Context, CRUD string) error {
 	if CRUD != OP_CREATE {
 		return fmt.Errorf("test type is not supported")
 	}
@@ -25,7 +30,7 @@ func (t *ReportCRUD) Validate(CRUD string) error {
 	return nil
 }
 
-func (t *ReportCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *ReportCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	if CRUD != OP_CREATE {
 		return fmt.Errorf("can only create a report")
 	}
@@ -36,7 +41,7 @@ func (t *ReportCRUD) GetModule() string {
 	return MOD_REPORT
 }
 
-func (t *ReportCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ReportCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	project, err := cx1client.GetProjectByName(t.ProjectName)
 	if err != nil {
 		return err
@@ -72,7 +77,7 @@ func (t *ReportCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.
 		return err
 	}
 
-	reportURL, err := cx1client.ReportPollingByID(reportID)
+	reportURL, err := pollReport(ctx, cx1client, logger, reportID)
 	if err != nil {
 		return err
 	}
@@ -85,14 +90,39 @@ func (t *ReportCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.
 	return nil
 }
 
-func (t *ReportCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+// polls the status of the report until it is generated or the context is done, and returns the URL of the report
+func pollReport(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, reportID string) (string, error) {
+	logger.Infof("Polling status of report %v", reportID)
+	for {
+		status, err := cx1client.GetReportStatusByID(reportID)
+		if err != nil {
+			return "", err
+		}
+		logger.Infof(" - %v", status.Status)
+
+		switch status.Status {
+		case "completed":
+			return status.ReportURL, nil
+		case "failed":
+			return "", fmt.Errorf("report generation failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("report polling aborted: %s", ctx.Err())
+		case <-time.After(reportPollingDelay):
+		}
+	}
+}
+
+func (t *ReportCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	return fmt.Errorf("not supported")
 }
 
-func (t *ReportCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ReportCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	return fmt.Errorf("not supported")
 }
 
-func (t *ReportCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ReportCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	return fmt.Errorf("not supported")
 }
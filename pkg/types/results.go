@@ -1,6 +1,7 @@
 package types
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strconv"
@@ -10,10 +11,7 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func (t *ResultCRUD) Validate(CRUD string) error {
-	if CRUD == OP_UPDATE && (len(t.Results.SAST)+len(t.Results.SCA)+len(t.Results.KICS) == 0) {
-		return fmt.Errorf("must read before updating")
-	}
+func (t *ResultCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.Type == "" {
 		return fmt.Errorf("result type not specified, should be one of: SAST, SCA, KICS")
 	}
@@ -24,10 +22,14 @@ func (t *ResultCRUD) Validate(CRUD string) error {
 		return fmt.Errorf("result number is missing (starting from 1)")
 	}
 
+	if CRUD == OP_UPDATE && (len(t.Results.SAST)+len(t.Results.SCA)+len(t.Results.KICS) == 0) {
+		return fmt.Errorf("%w before updating", ErrNotRead)
+	}
+
 	return nil
 }
 
-func (t *ResultCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *ResultCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	if !cx1client.IsEngineAllowed(t.Type) {
 		return fmt.Errorf("test attempts to access results from engine %v but this is not supported in the license and will be skipped", t.Type)
 	}
@@ -168,11 +170,11 @@ func (t *ResultCRUD) Filter(results *Cx1ClientGo.ScanResultSet) Cx1ClientGo.Scan
 	return final_results
 }
 
-func (t *ResultCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ResultCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	return fmt.Errorf("not implemented")
 }
 
-func (t *ResultCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ResultCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	project, err := cx1client.GetProjectByName(t.ProjectName)
 	if err != nil {
 		return err
@@ -218,7 +220,7 @@ func (t *ResultCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 	return nil
 }
 
-func (t *ResultCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ResultCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	switch t.Type {
 	case "SAST":
 		if len(t.Results.SAST) == 0 {
@@ -243,6 +245,6 @@ func (t *ResultCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.
 	return fmt.Errorf("unknown type: %v", t.Type)
 }
 
-func (t *ResultCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ResultCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	return fmt.Errorf("not implemented")
 }
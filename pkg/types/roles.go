@@ -1,25 +1,26 @@
 package types
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *RoleCRUD) Validate(CRUD string) error {
-	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Role == nil {
-		return fmt.Errorf("must read before updating or deleting")
-	}
-
+func (t *RoleCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.Name == "" {
 		return fmt.Errorf("role name is missing")
 	}
 
+	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.Role == nil {
+		return fmt.Errorf("%w before updating or deleting", ErrNotRead)
+	}
+
 	return nil
 }
 
-func (t *RoleCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *RoleCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	return nil
 }
 
@@ -98,16 +99,17 @@ func updateRole(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, t *Role
 	return nil
 }
 
-func (t *RoleCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *RoleCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	test_Role, err := cx1client.CreateAppRole(t.Name, "cx1e2e test")
 	if err != nil {
 		return err
 	}
 	t.Role = &test_Role
+	Registry.Add(CreatedObject{Module: MOD_ROLE, ID: test_Role.RoleID, Name: test_Role.Name})
 	return updateRole(cx1client, logger, t)
 }
 
-func (t *RoleCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *RoleCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	test_Role, err := cx1client.GetRoleByName(t.Name)
 	if err != nil {
 		return err
@@ -116,10 +118,19 @@ func (t *RoleCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logg
 	return nil
 }
 
-func (t *RoleCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *RoleCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	return updateRole(cx1client, logger, t)
 }
 
-func (t *RoleCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
-	return cx1client.DeleteRoleByID(t.Role.RoleID)
+func (t *RoleCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
+	err := cx1client.DeleteRoleByID(t.Role.RoleID)
+	if err != nil {
+		return err
+	}
+	Registry.Remove(CreatedObject{Module: MOD_ROLE, ID: t.Role.RoleID})
+	return nil
+}
+
+func (t *RoleCRUD) DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object CreatedObject) error {
+	return cx1client.DeleteRoleByID(object.ID)
 }
@@ -1,19 +1,20 @@
 package types
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *ScanCRUD) Validate(CRUD string) error {
-	if CRUD == OP_DELETE && t.Scan == nil {
-		return fmt.Errorf("must read before deleting")
-	}
+// how long to wait for a scan to be canceled after it exceeded its timeout, unless the test has a CancelTimeout
+const ScanCancelTimeout = 5 * time.Minute
 
+func (t *ScanCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.Project == "" {
 		return fmt.Errorf("project name is missing")
 	}
@@ -21,10 +22,28 @@ func (t *ScanCRUD) Validate(CRUD string) error {
 		return fmt.Errorf("project repository and branch or zip file is missing")
 	}
 
+	if CRUD == OP_DELETE && t.Scan == nil {
+		return fmt.Errorf("%w before deleting", ErrNotRead)
+	}
+	if t.CancelTimeout < 0 {
+		return fmt.Errorf("scan CancelTimeout can not be negative")
+	}
+
 	return nil
 }
 
-func (t *ScanCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+// how long the test may take to cancel its scan after it exceeded its timeout
+func (t *ScanCRUD) GetCancelTimeout() time.Duration {
+	if !t.Cancel {
+		return 0
+	}
+	if t.CancelTimeout > 0 {
+		return time.Duration(t.CancelTimeout) * time.Second
+	}
+	return ScanCancelTimeout
+}
+
+func (t *ScanCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	if CRUD == OP_UPDATE {
 		return fmt.Errorf("updating a scan is not supported")
 	}
@@ -36,7 +55,7 @@ func (t *ScanCRUD) GetModule() string {
 	return MOD_SCAN
 }
 
-func (t *ScanCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ScanCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	project, err := cx1client.GetProjectByName(t.Project)
 	if err != nil {
 		return err
@@ -82,6 +101,9 @@ func (t *ScanCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 		if err != nil {
 			return err
 		}
+		if err = ctx.Err(); err != nil { // the upload can take long enough for the test to time out, do not start a scan after that
+			return err
+		}
 
 		test_Scan, err = cx1client.ScanProjectZipByID(project.ProjectID, uploadURL, t.Branch, scanConfigs, map[string]string{})
 		if err != nil {
@@ -91,15 +113,17 @@ func (t *ScanCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 
 	t.Scan = &test_Scan
 	if t.WaitForEnd {
-		test_Scan, err = cx1client.ScanPollingWithTimeout(&test_Scan, true, scanDelay, t.Timeout)
+		test_Scan, err = pollScan(ctx, cx1client, logger, test_Scan, scanDelay)
 		if err != nil {
-			if err.Error()[:12] == "scan polling" && t.Cancel {
+			if ctx.Err() != nil && t.Cancel {
 				logger.Infof("Scan %v took too long and will be canceled", test_Scan.String())
 				err = cx1client.CancelScanByID(test_Scan.ScanID)
 				if err != nil {
 					return err
 				}
-				test_Scan, err = cx1client.ScanPollingWithTimeout(&test_Scan, true, 30, 300) // allow up to 5 minutes to cancel.
+				cancelCtx, cancel := context.WithTimeout(context.Background(), t.GetCancelTimeout())
+				defer cancel()
+				test_Scan, err = pollScan(cancelCtx, cx1client, logger, test_Scan, 5)
 				if err == nil {
 					return fmt.Errorf("scan took too long and was canceled")
 				} else {
@@ -175,7 +199,45 @@ func (t *ScanCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 	return nil
 }
 
-func (t *ScanCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+// polls the status of the scan until it finishes or the context is done
+func pollScan(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, scan Cx1ClientGo.Scan, delaySeconds int) (Cx1ClientGo.Scan, error) {
+	logger.Infof("Polling status of scan %v", scan.ScanID)
+
+	var err error
+	for !isScanFinished(scan.Status) {
+		scan, err = cx1client.GetScanByID(scan.ScanID)
+		if err != nil {
+			return scan, err
+		}
+
+		status := "no details"
+		workflow, err := cx1client.GetScanWorkflowByID(scan.ScanID)
+		if err != nil {
+			return scan, err
+		}
+		if len(workflow) > 0 {
+			status = workflow[len(workflow)-1].Info
+		}
+		logger.Infof(" - %v: %v", scan.Status, status)
+
+		if isScanFinished(scan.Status) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return scan, fmt.Errorf("scan polling aborted: %s", ctx.Err())
+		case <-time.After(time.Duration(delaySeconds) * time.Second):
+		}
+	}
+	return scan, nil
+}
+
+func isScanFinished(status string) bool {
+	return status == "Failed" || status == "Partial" || status == "Completed" || status == "Canceled"
+}
+
+func (t *ScanCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	project, err := cx1client.GetProjectByName(t.Project)
 	if err != nil {
 		return err
@@ -219,10 +281,31 @@ func (t *ScanCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logg
 	return nil
 }
 
-func (t *ScanCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ScanCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	return fmt.Errorf("not implemented")
 }
 
-func (t *ScanCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *ScanCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	return cx1client.DeleteScanByID(t.Scan.ScanID)
 }
+
+// cancels the scan started by the test if it is still running, scans with CancelOnTimeout were already canceled by the test
+func (t *ScanCRUD) CancelInFlight(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger) error {
+	if t.Scan == nil || t.Cancel || isScanFinished(t.Scan.Status) {
+		return nil
+	}
+	logger.Infof("Canceling scan %v since the run was interrupted", t.Scan.String())
+	return cx1client.CancelScanByID(t.Scan.ScanID)
+}
+
+// makes the zip file relative to the configuration file
+func (t *ScanCRUD) ResolveFiles(locate func(file string) (string, error)) error {
+	if t.ZipFile != "" {
+		filePath, err := locate(t.ZipFile)
+		if err != nil {
+			return fmt.Errorf("error locating scan zipfile %v", t.ZipFile)
+		}
+		t.ZipFile = filePath
+	}
+	return nil
+}
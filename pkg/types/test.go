@@ -1,6 +1,11 @@
 package types
 
-import "strings"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
 
 func (c CRUDTest) IsNegative() bool {
 	return c.FailTest
@@ -20,10 +25,18 @@ func (c CRUDTest) IsType(CRUD string) bool {
 	return false
 }
 
+func (c CRUDTest) GetTestType() string {
+	return c.Test
+}
+
 func (c CRUDTest) GetSource() string {
 	return c.TestSource
 }
 
+func (c *CRUDTest) SetSource(source string) {
+	c.TestSource = source
+}
+
 func (c CRUDTest) GetFlags() []string {
 	return c.Flags
 }
@@ -31,3 +44,82 @@ func (c CRUDTest) GetFlags() []string {
 func (c CRUDTest) IsForced() bool {
 	return c.ForceRun
 }
+
+func (c CRUDTest) GetDependencies() []string {
+	return c.DependsOn
+}
+
+func (c CRUDTest) GetTags() []string {
+	return c.TestTags
+}
+
+func (c *CRUDTest) AddTags(tags []string) {
+	c.TestTags = append(c.TestTags, tags...)
+}
+
+func (c CRUDTest) GetCaptures() map[string]string {
+	return c.Capture
+}
+
+func (c CRUDTest) GetExpectations() []Assertion {
+	return c.Expect
+}
+
+func (c CRUDTest) GetMaxDuration() float64 {
+	return c.MaxDuration
+}
+
+func (c CRUDTest) GetTimeout() int {
+	return c.Timeout
+}
+
+func (c CRUDTest) GetRetry() *RetryPolicy {
+	return c.Retry
+}
+
+func (r *RetryPolicy) Validate() error {
+	if r.Attempts < 0 || r.Backoff < 0 || r.MaxDelay < 0 {
+		return fmt.Errorf("retry attempts, backoff and max delay can not be negative")
+	}
+	for _, pattern := range r.RetryOn {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid retry pattern %v: %s", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (r *RetryPolicy) GetAttempts() int {
+	if r == nil || r.Attempts < 1 {
+		return 1
+	}
+	return r.Attempts
+}
+
+// should a test which failed with this error be attempted again
+func (r *RetryPolicy) Matches(err error) bool {
+	if len(r.RetryOn) == 0 {
+		return true
+	}
+	for _, pattern := range r.RetryOn {
+		if matched, _ := regexp.MatchString(pattern, err.Error()); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// how long to wait after the given (1-based) failed attempt
+func (r *RetryPolicy) GetDelay(attempt int) time.Duration {
+	delay := r.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if r.MaxDelay > 0 && delay > r.MaxDelay {
+			break
+		}
+	}
+	if r.MaxDelay > 0 && delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	return time.Duration(delay * float64(time.Second))
+}
@@ -0,0 +1,54 @@
+package types
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyGetDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		delay   time.Duration
+	}{
+		{"no backoff", RetryPolicy{}, 3, 0},
+		{"first retry waits the backoff", RetryPolicy{Backoff: 2}, 1, 2 * time.Second},
+		{"doubles after each attempt", RetryPolicy{Backoff: 2}, 3, 8 * time.Second},
+		{"fractional backoff", RetryPolicy{Backoff: 0.5}, 2, time.Second},
+		{"limited by max delay", RetryPolicy{Backoff: 2, MaxDelay: 5}, 3, 5 * time.Second},
+		{"backoff above max delay", RetryPolicy{Backoff: 10, MaxDelay: 5}, 1, 5 * time.Second},
+		{"many attempts do not overflow", RetryPolicy{Backoff: 1, MaxDelay: 60}, 1000, 60 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if delay := test.policy.GetDelay(test.attempt); delay != test.delay {
+				t.Errorf("expected %v, got %v", test.delay, delay)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		retryOn []string
+		err     string
+		matches bool
+	}{
+		{"any error without patterns", nil, "boom", true},
+		{"matching pattern", []string{"timeout", "50[0-9]"}, "status 503", true},
+		{"no matching pattern", []string{"timeout"}, "not found", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := RetryPolicy{RetryOn: test.retryOn}
+			if matches := policy.Matches(fmt.Errorf("%v", test.err)); matches != test.matches {
+				t.Errorf("expected %v, got %v", test.matches, matches)
+			}
+		})
+	}
+}
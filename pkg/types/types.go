@@ -1,6 +1,7 @@
 package types
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -31,6 +32,9 @@ const (
 	OP_DELETE = "Delete"
 )
 
+// returned by Validate when a test needs an object which is only available after a [R]ead test
+var ErrNotRead = errors.New("must read")
+
 var RepoCreds *regexp.Regexp = regexp.MustCompile(`//(.*)@`)
 
 type EnabledEngines struct {
@@ -56,11 +60,25 @@ func (e EnabledEngines) IsEnabled(engine string) bool {
 }
 
 type CRUDTest struct {
-	Test       string   `yaml:"Test"`         // CRUD [create, read, update, delete]
-	FailTest   bool     `yaml:"FailTest"`     // is it a negative test
-	Flags      []string `yaml:"FeatureFlags"` // are there specific feature flags needed for this test
-	TestSource string   // filename
-	ForceRun   bool     `yaml:"ForceRun"` // should this test run even if it is unsupported by the backend (unlicensed engine, disabled flag). this is to force a failed test.
+	Test        string            `yaml:"Test"`         // CRUD [create, read, update, delete]
+	FailTest    bool              `yaml:"FailTest"`     // is it a negative test
+	Flags       []string          `yaml:"FeatureFlags"` // are there specific feature flags needed for this test
+	TestSource  string            // filename
+	ForceRun    bool              `yaml:"ForceRun"`    // should this test run even if it is unsupported by the backend (unlicensed engine, disabled flag). this is to force a failed test.
+	DependsOn   []string          `yaml:"DependsOn"`   // names of test sets which must pass before this test can run
+	Retry       *RetryPolicy      `yaml:"Retry"`       // overrides the retry policy of the test set and config
+	Timeout     int               `yaml:"Timeout"`     // seconds after which each attempt of the test is aborted and failed
+	TestTags    []string          `yaml:"TestTags"`    // free-form tags used to select tests, also read from "Tags" unless the object has Tags of its own
+	Capture     map[string]string `yaml:"Capture"`     // variable name -> path of a field of the test object, eg: Scan.ScanID, stored after each successful operation
+	Expect      []Assertion       `yaml:"Expect"`      // checks of the fields of the object fetched by a [R]ead test
+	MaxDuration float64           `yaml:"MaxDuration"` // seconds, a test which passes but takes longer is reported as slow
+}
+
+type RetryPolicy struct {
+	Attempts int      `yaml:"Attempts"` // total number of attempts including the first one
+	Backoff  float64  `yaml:"Backoff"`  // seconds to wait before the first retry, doubled for every following retry
+	MaxDelay float64  `yaml:"MaxDelay"` // maximum seconds to wait between attempts
+	RetryOn  []string `yaml:"RetryOn"`  // regular expressions matched against the error, any error is retried if empty
 }
 
 type AccessAssignmentCRUD struct {
@@ -175,14 +193,13 @@ type ImportCRUD struct {
 	EncryptionKey  string `yaml:"EncryptionKey"`
 	ProjectMapFile string `yaml:"ProjectMapFile"`
 	Parent         string `yaml:"Parent"`
-	TimeoutSeconds int    `yaml:"Timeout"`
 }
 
 func (o ImportCRUD) String() string {
-	if o.TimeoutSeconds == 0 {
+	if o.Timeout == 0 {
 		return o.Name
 	} else {
-		return fmt.Sprintf("%v (%d sec timeout)", o.Name, o.TimeoutSeconds)
+		return fmt.Sprintf("%v (%d sec timeout)", o.Name, o.Timeout)
 	}
 }
 
@@ -427,8 +444,8 @@ type ScanCRUD struct {
 	ZipFile       string      `yaml:"ZipFile"`
 	Preset        string      `yaml:"Preset"`
 	Status        string      `yaml:"Status"`
-	Timeout       int         `yaml:"Timeout"`
 	Cancel        bool        `yaml:"CancelOnTimeout"`
+	CancelTimeout int         `yaml:"CancelTimeout"` // seconds to wait for a scan canceled on timeout to stop, default 300
 	Filter        *ScanFilter `yaml:"Filter"`
 	Cx1ScanFilter *Cx1ClientGo.ScanFilter
 	Scan          *Cx1ClientGo.Scan
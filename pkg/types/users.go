@@ -1,17 +1,14 @@
 package types
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/cxpsemea/Cx1ClientGo"
 	"github.com/sirupsen/logrus"
 )
 
-func (t *UserCRUD) Validate(CRUD string) error {
-	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.User == nil {
-		return fmt.Errorf("must read before updating or deleting")
-	}
-
+func (t *UserCRUD) Validate(ctx context.Context, CRUD string) error {
 	if t.Name == "" {
 		return fmt.Errorf("user name is missing")
 	}
@@ -19,10 +16,14 @@ func (t *UserCRUD) Validate(CRUD string) error {
 		return fmt.Errorf("user email is missing")
 	}
 
+	if (CRUD == OP_UPDATE || CRUD == OP_DELETE) && t.User == nil {
+		return fmt.Errorf("%w before updating or deleting", ErrNotRead)
+	}
+
 	return nil
 }
 
-func (t *UserCRUD) IsSupported(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
+func (t *UserCRUD) IsSupported(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, CRUD string, Engines *EnabledEngines) error {
 	return nil
 }
 
@@ -116,7 +117,7 @@ func updateUserFromConfig(cx1client *Cx1ClientGo.Cx1Client, t *UserCRUD) error {
 	return nil
 }
 
-func (t *UserCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *UserCRUD) RunCreate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	var test_User Cx1ClientGo.User
 	test_User.UserName = t.Name
 	test_User.Email = t.Email
@@ -127,6 +128,7 @@ func (t *UserCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 	}
 
 	t.User = &test_User
+	Registry.Add(CreatedObject{Module: MOD_USER, ID: test_User.UserID, Name: test_User.UserName})
 
 	err = updateUserFromConfig(cx1client, t)
 	if err != nil {
@@ -135,16 +137,26 @@ func (t *UserCRUD) RunCreate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 	return nil
 }
 
-func (t *UserCRUD) RunRead(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *UserCRUD) RunRead(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	test_User, err := cx1client.GetUserByUserName(t.Name)
 	if err != nil {
 		return err
 	}
+
+	_, err = cx1client.GetUserGroups(&test_User)
+	if err != nil {
+		return fmt.Errorf("failed to get user's groups: %s", err)
+	}
+	_, err = cx1client.GetUserRoles(&test_User)
+	if err != nil {
+		return fmt.Errorf("failed to get user's roles: %s", err)
+	}
+
 	t.User = &test_User
 	return nil
 }
 
-func (t *UserCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *UserCRUD) RunUpdate(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
 	err := updateUserFromConfig(cx1client, t)
 	if err != nil {
 		return err
@@ -153,12 +165,17 @@ func (t *UserCRUD) RunUpdate(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Lo
 	return cx1client.UpdateUser(t.User)
 }
 
-func (t *UserCRUD) RunDelete(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines) error {
+func (t *UserCRUD) RunDelete(ctx context.Context, cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, Engines *EnabledEngines, Registry *CleanupRegistry) error {
 	err := cx1client.DeleteUser(t.User)
 	if err != nil {
 		return err
 	}
+	Registry.Remove(CreatedObject{Module: MOD_USER, ID: t.User.UserID})
 
 	t.User = nil
 	return nil
 }
+
+func (t *UserCRUD) DeleteObject(cx1client *Cx1ClientGo.Cx1Client, logger *logrus.Logger, object CreatedObject) error {
+	return cx1client.DeleteUserByID(object.ID)
+}
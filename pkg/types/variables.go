@@ -0,0 +1,231 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var VariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+var variableReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// checks that a capture path such as Scan.ScanID or Results.SAST.0.Data.ResultHash can exist in the given type
+func CheckCapturePath(objectType reflect.Type, path string) error {
+	currentType, err := resolvePathType(objectType, path)
+	if err != nil {
+		return err
+	}
+	if !isSimpleKind(currentType.Kind()) {
+		return fmt.Errorf("%v does not resolve to a simple value but to %v", path, currentType)
+	}
+	return nil
+}
+
+// returns the value at the capture path as a string
+func ResolveCapturePath(object interface{}, path string) (string, error) {
+	value, err := resolvePath(object, path)
+	if err != nil {
+		return "", err
+	}
+	if !isSimpleKind(value.Kind()) {
+		return "", fmt.Errorf("%v does not resolve to a simple value but to %v", path, value.Type())
+	}
+	return fmt.Sprintf("%v", value.Interface()), nil
+}
+
+// returns the type at the end of the path, without pointers
+func resolvePathType(objectType reflect.Type, path string) (reflect.Type, error) {
+	currentType := objectType
+	for _, segment := range strings.Split(path, ".") {
+		for currentType.Kind() == reflect.Pointer {
+			currentType = currentType.Elem()
+		}
+
+		switch currentType.Kind() {
+		case reflect.Struct:
+			field, ok := findField(currentType, segment)
+			if !ok {
+				return nil, fmt.Errorf("%v has no field %v", currentType.Name(), segment)
+			}
+			currentType = field.Type
+		case reflect.Slice, reflect.Array:
+			if _, err := strconv.Atoi(segment); err != nil {
+				return nil, fmt.Errorf("%v is not a valid index", segment)
+			}
+			currentType = currentType.Elem()
+		case reflect.Map:
+			if currentType.Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("can not resolve %v in a map with %v keys", segment, currentType.Key())
+			}
+			currentType = currentType.Elem()
+		default:
+			return nil, fmt.Errorf("can not resolve %v in %v", segment, currentType)
+		}
+	}
+
+	for currentType.Kind() == reflect.Pointer {
+		currentType = currentType.Elem()
+	}
+	return currentType, nil
+}
+
+// returns the value at the end of the path, without pointers
+func resolvePath(object interface{}, path string) (reflect.Value, error) {
+	value := reflect.ValueOf(object)
+	segments := strings.Split(path, ".")
+	for id, segment := range segments {
+		for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return value, fmt.Errorf("%v is not set", strings.Join(segments[:id], "."))
+			}
+			value = value.Elem()
+		}
+
+		switch value.Kind() {
+		case reflect.Struct:
+			field, ok := findField(value.Type(), segment)
+			if !ok {
+				return value, fmt.Errorf("%v has no field %v", value.Type().Name(), segment)
+			}
+			value = value.FieldByIndex(field.Index)
+		case reflect.Slice, reflect.Array:
+			index, err := strconv.Atoi(segment)
+			if err != nil {
+				return value, fmt.Errorf("%v is not a valid index", segment)
+			}
+			if index < 0 || index >= value.Len() {
+				return value, fmt.Errorf("%v has no element %d", strings.Join(segments[:id], "."), index)
+			}
+			value = value.Index(index)
+		case reflect.Map:
+			value = value.MapIndex(reflect.ValueOf(segment))
+			if !value.IsValid() {
+				return value, fmt.Errorf("%v has no key %v", strings.Join(segments[:id], "."), segment)
+			}
+		default:
+			return value, fmt.Errorf("can not resolve %v in %v", segment, value.Type())
+		}
+	}
+
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return value, fmt.Errorf("%v is not set", path)
+		}
+		value = value.Elem()
+	}
+	return value, nil
+}
+
+// field names are matched case-insensitively, promoted fields of embedded structs are included
+func findField(structType reflect.Type, name string) (reflect.StructField, bool) {
+	return structType.FieldByNameFunc(func(fieldName string) bool {
+		return strings.EqualFold(fieldName, name)
+	})
+}
+
+func isSimpleKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// replaces ${name} references in the string fields which are set from the yaml config, of the common CRUDTest settings only Expect is changed
+// returns an error naming the first variable which is not defined
+func SubstituteVariables(object interface{}, lookup func(name string) (string, bool)) error {
+	return substituteValue(reflect.ValueOf(object), func(str string) (string, error) {
+		for _, match := range variableReference.FindAllStringSubmatch(str, -1) {
+			if _, ok := lookup(match[1]); !ok {
+				return str, fmt.Errorf("undefined variable '%v'", match[1])
+			}
+		}
+		return variableReference.ReplaceAllStringFunc(str, func(ref string) string {
+			replacement, _ := lookup(ref[2 : len(ref)-1])
+			return replacement
+		}), nil
+	})
+}
+
+// like SubstituteVariables, but references to other variables are left as they are to be substituted later
+func ExpandVariables(object interface{}, values map[string]string) {
+	_ = substituteValue(reflect.ValueOf(object), func(str string) (string, error) {
+		return variableReference.ReplaceAllStringFunc(str, func(ref string) string {
+			if replacement, ok := values[ref[2:len(ref)-1]]; ok {
+				return replacement
+			}
+			return ref
+		}), nil
+	})
+}
+
+// sets the fields of object which reference variables in template back to the template, other fields keep their values
+// so that a test which ran on a substituted copy keeps the state it found without losing the references for later operations
+func RestoreTemplates(object, template interface{}) {
+	value, original := reflect.ValueOf(object).Elem(), reflect.ValueOf(template).Elem()
+	for id := 0; id < value.NumField(); id++ {
+		field, originalField := value.Field(id), original.Field(id)
+		if value.Type().Field(id).Type == reflect.TypeOf(CRUDTest{}) {
+			field, originalField = field.FieldByName("Expect"), originalField.FieldByName("Expect")
+		}
+		if field.CanSet() && hasVariables(originalField) {
+			field.Set(originalField)
+		}
+	}
+}
+
+var errHasVariables = fmt.Errorf("has variables")
+
+func hasVariables(value reflect.Value) bool {
+	return substituteValue(value, func(str string) (string, error) {
+		return str, errHasVariables
+	}) == errHasVariables
+}
+
+func substituteValue(value reflect.Value, replace func(str string) (string, error)) error {
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !value.IsNil() {
+			return substituteValue(value.Elem(), replace)
+		}
+	case reflect.Struct:
+		for id := 0; id < value.NumField(); id++ {
+			field := value.Type().Field(id)
+			tag := field.Tag.Get("yaml")
+			if field.Type == reflect.TypeOf(CRUDTest{}) { // only the expected values of the common settings can use variables
+				if err := substituteValue(value.Field(id).FieldByName("Expect"), replace); err != nil {
+					return err
+				}
+				continue
+			}
+			if !field.IsExported() || tag == "" || tag == "-" {
+				continue
+			}
+			if err := substituteValue(value.Field(id), replace); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for id := 0; id < value.Len(); id++ {
+			if err := substituteValue(value.Index(id), replace); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		str := value.String()
+		if !strings.Contains(str, "${") || !value.CanSet() {
+			return nil
+		}
+		replaced, err := replace(str)
+		if err != nil {
+			return err
+		}
+		value.SetString(replaced)
+	}
+	return nil
+}
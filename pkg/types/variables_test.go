@@ -0,0 +1,56 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/cxpsemea/Cx1ClientGo"
+)
+
+func TestSubstituteVariablesKeepsTemplates(t *testing.T) {
+	variables := map[string]string{"suffix": "1", "team": "red"}
+	lookup := func(name string) (string, bool) {
+		value, ok := variables[name]
+		return value, ok
+	}
+
+	template := &GroupCRUD{Name: "group-${suffix}", Parent: "parent"}
+	template.Expect = []Assertion{{Field: "Name", Equals: strPtr("group-${suffix}")}}
+	template.Capture = map[string]string{"group": "Group.GroupID"}
+
+	for _, suffix := range []string{"1", "2"} {
+		variables["suffix"] = suffix
+		run := &GroupCRUD{}
+		*run = *template
+		run.Expect = append([]Assertion{}, template.Expect...)
+		run.Expect[0].Equals = strPtr(*template.Expect[0].Equals)
+		if err := SubstituteVariables(run, lookup); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if run.Name != "group-"+suffix || *run.Expect[0].Equals != "group-"+suffix {
+			t.Fatalf("expected substituted values, got %v and %v", run.Name, *run.Expect[0].Equals)
+		}
+
+		run.Group = &Cx1ClientGo.Group{GroupID: "id-" + suffix}
+		RestoreTemplates(run, template)
+		*template = *run
+
+		if template.Name != "group-${suffix}" || *template.Expect[0].Equals != "group-${suffix}" {
+			t.Errorf("expected the templates to be kept, got %v and %v", template.Name, *template.Expect[0].Equals)
+		}
+		if template.Group == nil || template.Group.GroupID != "id-"+suffix {
+			t.Errorf("expected the group found by the test to be kept, got %v", template.Group)
+		}
+		if template.Parent != "parent" {
+			t.Errorf("expected fields without variables to be kept, got %v", template.Parent)
+		}
+	}
+
+	variables = map[string]string{}
+	if err := SubstituteVariables(&GroupCRUD{Name: "group-${missing}"}, lookup); err == nil {
+		t.Errorf("expected an error for an undefined variable")
+	}
+}
+
+func strPtr(str string) *string {
+	return &str
+}